@@ -0,0 +1,292 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/db"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/netio"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// binary logs are shipped next to the dumps of the database, e.g. databaseName_-_binlog_-_binlog.000042.gz,
+// compressed and encrypted like the dumps
+
+// BinlogDestination is a destination that also keeps the binary logs of the database, for point-in-time recovery
+type BinlogDestination interface {
+	Destination
+
+	// return the binary logs of the database at the destination
+	ListBinlogs(dbName string) ([]Dump, error)
+
+	// copy the binary log of the given size to the destination
+	storeBinlog(name string, reader io.Reader, size int64) error
+}
+
+// binlogPrefix returns the start of the filenames of the binary logs of the database
+func binlogPrefix(dbName string) string {
+	return dbName + "_-_binlog_-_"
+}
+
+// BinlogOf returns the name of the binary log on the database server, from the name it is stored under
+func BinlogOf(dbName string, storedName string) string {
+	name := strings.TrimPrefix(storedName, binlogPrefix(dbName))
+	name = strings.TrimSuffix(name, ".age")
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.TrimSuffix(name, ".zst")
+}
+
+// ShipBinlog compresses, encrypts and stores a copy of the binary log at the destination. Returns the stored name.
+// The copy is written next to path first, scp needs to know its size up front
+func ShipBinlog(dest BinlogDestination, dbName string, binlog string, path string, compression string, recipients []string) (string, error) {
+
+	name := binlogPrefix(dbName) + binlog + netio.CompressionExt(compression) + netio.EncryptionExt(recipients)
+	encoded := path + netio.CompressionExt(compression) + netio.EncryptionExt(recipients) + ".tmp"
+	if err := encodeTo(path, encoded, compression, recipients); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := os.Remove(encoded); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	fd, err := os.Open(encoded)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := fd.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+	info, err := fd.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if err = dest.storeBinlog(name, fd, info.Size()); err != nil {
+		return "", errors.New("failed to ship binary log " + binlog + " to " + dest.String() + ": " + err.Error())
+	}
+	logging.Info("shipped binary log: "+name+" to "+dest.String(), logging.Fields{Event: logging.Binlog, DB: dbName, Dump: name, Destination: dest.String(), Bytes: info.Size()})
+
+	return name, nil
+}
+
+// encodeTo writes the compressed and encrypted file to path, the reverse of decodeTo
+func encodeTo(src string, path string, compression string, recipients []string) error {
+
+	fd, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	var encrypted io.ReadCloser
+	compressed, err := netio.Compress(fd, compression)
+	if err == nil {
+		encrypted, err = netio.Encrypt(compressed, recipients)
+	}
+	if err != nil {
+		_ = fd.Close()
+		return err
+	}
+	defer func() {
+		if err := encrypted.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, encrypted); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// binary logs are uploaded under a temporary name and renamed into place, a partial one never shows up.
+// transfer selects how, sftp (default) or scp
+func (d *sshDestination) storeBinlog(name string, reader io.Reader, size int64) error {
+
+	if d.transfer != "scp" {
+		return netio.UploadSftp(reader, name, d.workingDir, 0600, d.SSH)
+	}
+
+	if err := netio.UploadScp(reader, size, name+".part", d.workingDir, "0600", d.SSH); err != nil {
+		if _, rmErr := d.exe.RemoteCmd(d.SSH, "rm -f "+d.workingDir+name+".part"); rmErr != nil {
+			logging.Error(rmErr, logging.Fields{Event: logging.Binlog, Dump: name, Destination: d.String()})
+		}
+		return err
+	}
+	_, err := d.exe.RemoteCmd(d.SSH, "mv -f "+d.workingDir+name+".part "+d.workingDir+name)
+	return err
+}
+
+// return the binary logs of the database in the working directory of the receiver
+func (d *sshDestination) ListBinlogs(dbName string) ([]Dump, error) {
+
+	result, err := d.exe.RemoteCmd(d.SSH, "find "+d.workingDir+" -maxdepth 1 -type f -name '"+binlogPrefix(dbName)+"*' ! -name '*.part' -printf '"+findFormat+"'")
+	if err != nil {
+		return nil, err
+	}
+	return parseFind(result)
+}
+
+// ListBinlogs returns the binary logs of the database in the working dir of the receiver
+func ListBinlogs(workingDir string, dbName string) ([]Dump, error) {
+
+	files, err := ioutil.ReadDir(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var binlogs []Dump
+	for _, file := range files {
+		if !file.IsDir() && strings.HasPrefix(file.Name(), binlogPrefix(dbName)) && !strings.HasSuffix(file.Name(), ".part") {
+			binlogs = append(binlogs, Dump{Name: file.Name(), Size: file.Size(), ModTime: file.ModTime()})
+		}
+	}
+	return binlogs, nil
+}
+
+// BinlogChain holds what replays the changes since a dump: the stored binary logs from the one the dump was taken in,
+// in order, decoded into dir, and the position in the first one the dump was taken at. Remove cleans up the decoded ones
+type BinlogChain struct {
+	dump     string
+	binlogs  []string
+	files    []string
+	dir      string
+	position uint64
+}
+
+// ResolveBinlogs finds the binary logs in the working dir to replay on top of the dump and decodes them, before
+// anything is restored. The binary log the dump was taken in must have been shipped, none may be missing after it and
+// all of them must decode
+func ResolveBinlogs(workingDir string, dbName string, dumpName string, identityFile string) (*BinlogChain, error) {
+
+	dump, err := openDecoded(workingDir+dumpName, identityFile)
+	if err != nil {
+		return nil, err
+	}
+	start, position, err := db.BinlogPosition(dump)
+	if closeErr := dump.Close(); closeErr != nil {
+		logging.Error(closeErr, logging.Fields{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := ListBinlogs(workingDir, dbName)
+	if err != nil {
+		return nil, err
+	}
+	names, err := binlogsFrom(dbName, stored, start)
+	if err != nil {
+		return nil, errors.New("can't replay " + dumpName + ": " + err.Error())
+	}
+
+	// mysqlbinlog reads plain binary logs, decode them next to the working dir
+	tmpDir, err := ioutil.TempDir(workingDir, "~.binlogs.")
+	if err != nil {
+		return nil, err
+	}
+	chain := &BinlogChain{dump: dumpName, binlogs: names, dir: tmpDir, position: position}
+	for _, name := range names {
+		path, err := decodeTo(workingDir+name, tmpDir+"/"+BinlogOf(dbName, name), identityFile)
+		if err != nil {
+			chain.Remove()
+			return nil, errors.New("can't replay " + dumpName + ": " + name + ": " + err.Error())
+		}
+		chain.files = append(chain.files, path)
+	}
+
+	return chain, nil
+}
+
+// Remove deletes the decoded binary logs of the chain
+func (chain *BinlogChain) Remove() {
+
+	if err := os.RemoveAll(chain.dir); err != nil {
+		logging.Error(err, logging.Fields{})
+	}
+}
+
+// ReplayBinlogs applies the changes of the chain up to stop. The dump of the chain must have been restored already
+func ReplayBinlogs(dB db.Binlogs, chain *BinlogChain, stop time.Time, exe *exec.Exec) error {
+
+	names := chain.binlogs
+	logging.Info("replaying binary logs "+BinlogOf(dB.Name(), names[0])+" to "+BinlogOf(dB.Name(), names[len(names)-1])+" up to "+stop.UTC().Format("2006-01-02 15:04:05")+" UTC", logging.Fields{Event: logging.Restore, DB: dB.Name(), Dump: chain.dump})
+	return dB.ReplayBinlogs(exe, chain.files, chain.position, stop)
+}
+
+// binlogsFrom returns the stored binary logs from start onwards, in order. They must follow each other without gaps
+func binlogsFrom(dbName string, stored []Dump, start string) ([]string, error) {
+
+	var names []string
+	for _, binlog := range stored {
+		if BinlogOf(dbName, binlog.Name) >= start {
+			names = append(names, binlog.Name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return BinlogOf(dbName, names[i]) < BinlogOf(dbName, names[j]) })
+
+	if len(names) == 0 || BinlogOf(dbName, names[0]) != start {
+		return nil, errors.New("binary log " + start + " has not been shipped")
+	}
+	for i := 1; i < len(names); i++ {
+		previous, err := binlogSequence(BinlogOf(dbName, names[i-1]))
+		if err != nil {
+			return nil, err
+		}
+		current, err := binlogSequence(BinlogOf(dbName, names[i]))
+		if err != nil {
+			return nil, err
+		}
+		if current != previous+1 {
+			return nil, errors.New("binary logs are missing between " + BinlogOf(dbName, names[i-1]) + " and " + BinlogOf(dbName, names[i]))
+		}
+	}
+
+	return names, nil
+}
+
+// binlogSequence returns the sequence number of a binary log, e.g. 42 for binlog.000042
+func binlogSequence(binlog string) (int, error) {
+	return strconv.Atoi(binlog[strings.LastIndex(binlog, ".")+1:])
+}
+
+// decodeTo writes the decrypted and decompressed file to path, the reverse of encodeTo
+func decodeTo(src string, path string, identityFile string) (string, error) {
+
+	plain, err := openDecodedWith(src, identityFile, netio.DecompressFile)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := plain.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	fd, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(fd, plain); err != nil {
+		_ = fd.Close()
+		return "", err
+	}
+	return path, fd.Close()
+}
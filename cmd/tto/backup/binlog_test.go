@@ -0,0 +1,205 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"filippo.io/age"
+	"github.com/ctomkow/tto/cmd/tto/db"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBinlogOf(t *testing.T) {
+
+	var tests = []struct {
+		stored   string
+		expected string
+	}{
+		{"databaseName_-_binlog_-_binlog.000042", "binlog.000042"},
+		{"databaseName_-_binlog_-_binlog.000042.gz", "binlog.000042"},
+		{"databaseName_-_binlog_-_mysql-bin.000007.zst.age", "mysql-bin.000007"},
+	}
+
+	for _, test := range tests {
+		if found := BinlogOf("databaseName", test.stored); found != test.expected {
+			t.Errorf("Binlog of test failed; found, expected: %s, %s", found, test.expected)
+		}
+	}
+}
+
+func TestListBinlogs(t *testing.T) {
+
+	dir := t.TempDir() + "/"
+	for _, name := range []string{
+		"databaseName_-_binlog_-_binlog.000042.gz",
+		"databaseName_-_binlog_-_binlog.000043.gz.part",
+		"databaseName_-_20190802120000.sql.gz",
+		"other_-_binlog_-_binlog.000042.gz",
+	} {
+		if err := ioutil.WriteFile(dir+name, []byte("binlog"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	binlogs, err := ListBinlogs(dir, "databaseName")
+	if err != nil || len(binlogs) != 1 || binlogs[0].Name != "databaseName_-_binlog_-_binlog.000042.gz" {
+		t.Errorf("List binlogs test failed; found, expected: %+v %#v, %s", binlogs, err, "the shipped binlog only")
+	}
+
+	// binary logs aren't dumps
+	dumps, err := ListDumps(dir, "databaseName")
+	if err != nil || len(dumps) != 1 || dumps[0].Name != "databaseName_-_20190802120000.sql.gz" {
+		t.Errorf("List binlogs test failed; found, expected: %+v %#v, %s", dumps, err, "the dump only")
+	}
+}
+
+func TestBinlogsFrom(t *testing.T) {
+
+	stored := func(names ...string) []Dump {
+		var dumps []Dump
+		for _, name := range names {
+			dumps = append(dumps, Dump{Name: "databaseName_-_binlog_-_" + name + ".gz"})
+		}
+		return dumps
+	}
+
+	names, err := binlogsFrom("databaseName", stored("binlog.000043", "binlog.000041", "binlog.000042", "binlog.000044"), "binlog.000042")
+	if err != nil || len(names) != 3 || BinlogOf("databaseName", names[0]) != "binlog.000042" || BinlogOf("databaseName", names[2]) != "binlog.000044" {
+		t.Errorf("Binlogs from test failed; found, expected: %v %#v, %s", names, err, "binlog.000042 to binlog.000044")
+	}
+
+	// the binary log of the dump must have been shipped
+	if _, err = binlogsFrom("databaseName", stored("binlog.000043"), "binlog.000042"); err == nil {
+		t.Errorf("Binlogs from test failed; found, expected: %#v, %s", err, "not shipped err")
+	}
+
+	// no gaps
+	if _, err = binlogsFrom("databaseName", stored("binlog.000042", "binlog.000044"), "binlog.000042"); err == nil {
+		t.Errorf("Binlogs from test failed; found, expected: %#v, %s", err, "missing binlogs err")
+	}
+}
+
+func TestSshDestination_StoreBinlogScp(t *testing.T) {
+
+	sh, workingDir := newFakeReceiver(t)
+	dest := NewSSHDestination(sh, new(exec.Exec), workingDir, "scp").(*sshDestination)
+	name := "databaseName_-_binlog_-_binlog.000042"
+	contents := "binary log"
+
+	if err := dest.storeBinlog(name, strings.NewReader(contents), int64(len(contents))); err != nil {
+		t.Fatalf("Store binlog scp test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	stored, err := ioutil.ReadFile(workingDir + name)
+	if err != nil || string(stored) != contents {
+		t.Errorf("Store binlog scp test failed; found, expected: %q %#v, %q", stored, err, contents)
+	}
+
+	// scp failing is an error, nothing is renamed into place
+	missing := NewSSHDestination(sh, new(exec.Exec), workingDir+"missing/", "scp").(*sshDestination)
+	if err = missing.storeBinlog(name, strings.NewReader(contents), int64(len(contents))); err == nil {
+		t.Errorf("Store binlog scp test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+
+	// a short binary log must not be stored either
+	if err = dest.storeBinlog(name+".gz", strings.NewReader(contents), int64(len(contents))+1); err == nil {
+		t.Errorf("Store binlog scp test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	if _, err = os.Stat(workingDir + name + ".gz"); !os.IsNotExist(err) {
+		t.Errorf("Store binlog scp test failed; found, expected: %#v, %s", err, "not exist err")
+	}
+}
+
+// a database that records the binary logs it's asked to replay
+type fakeBinlogs struct {
+	db.Binlogs
+	replayed []string
+	position uint64
+}
+
+func (f *fakeBinlogs) Name() string { return "databaseName" }
+
+func (f *fakeBinlogs) ReplayBinlogs(exe *exec.Exec, files []string, position uint64, stop time.Time) error {
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		f.replayed = append(f.replayed, string(contents))
+	}
+	f.position = position
+	return nil
+}
+
+func TestReplayBinlogs_RoundTrip(t *testing.T) {
+
+	workingDir := t.TempDir() + "/"
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(t.TempDir(), "identity.txt")
+	if err = ioutil.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	recipients := []string{identity.Recipient().String()}
+
+	dumpName := "databaseName_-_20190802120000.sql"
+	dump := "-- CHANGE MASTER TO MASTER_LOG_FILE='binlog.000042', MASTER_LOG_POS=157;\nCREATE TABLE t (id int);\n"
+	if err = ioutil.WriteFile(workingDir+dumpName, []byte(dump), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// binary logs are stored compressed and encrypted as the sender ships them
+	binlogs := []struct {
+		name        string
+		compression string
+		contents    string
+	}{
+		{"databaseName_-_binlog_-_binlog.000042.gz.age", "gzip", "first binary log"},
+		{"databaseName_-_binlog_-_binlog.000043.zst.age", "zstd", "second binary log"},
+	}
+	for _, binlog := range binlogs {
+		src := filepath.Join(t.TempDir(), BinlogOf("databaseName", binlog.name))
+		if err = ioutil.WriteFile(src, []byte(binlog.contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err = encodeTo(src, workingDir+binlog.name, binlog.compression, recipients); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chain, err := ResolveBinlogs(workingDir, "databaseName", dumpName, identityFile)
+	if err != nil {
+		t.Fatalf("Replay binlogs round trip test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	dB := new(fakeBinlogs)
+	if err = ReplayBinlogs(dB, chain, time.Now(), new(exec.Exec)); err != nil {
+		t.Fatalf("Replay binlogs round trip test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if len(dB.replayed) != 2 || dB.replayed[0] != binlogs[0].contents || dB.replayed[1] != binlogs[1].contents || dB.position != 157 {
+		t.Errorf("Replay binlogs round trip test failed; found, expected: %q %d, %s", dB.replayed, dB.position, "both binary logs from 157")
+	}
+
+	chain.Remove()
+	if _, err = os.Stat(chain.dir); !os.IsNotExist(err) {
+		t.Errorf("Replay binlogs round trip test failed; found, expected: %#v, %s", err, "decoded binary logs removed")
+	}
+
+	// a binary log that doesn't decode fails the chain, before anything is restored
+	if err = ioutil.WriteFile(workingDir+"databaseName_-_binlog_-_binlog.000044.gz", []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ResolveBinlogs(workingDir, "databaseName", dumpName, identityFile); err == nil {
+		t.Errorf("Replay binlogs corrupt test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	files, _ := filepath.Glob(workingDir + "~.binlogs.*")
+	if len(files) != 0 {
+		t.Errorf("Replay binlogs corrupt test failed; found, expected: %v, %s", files, "no decoded binary logs left")
+	}
+}
@@ -0,0 +1,82 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/inet"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/netio"
+	"io"
+	"strings"
+)
+
+// an S3-compatible bucket, for the off-site copy. There is no receiver restoring from it, so there is no .latest.dump.
+// The checksum manifest is stored next to the dump, like on a receiver
+type bucketDestination struct {
+	*inet.S3
+}
+
+func (d *bucketDestination) store(dumpName string, reader io.ReadCloser, ex *exec.Exec) error {
+
+	return netio.StreamS3(&reader, dumpName, ex, d.S3)
+}
+
+// an object can't be renamed, so the manifest follows the dump. Nothing restores straight from a bucket
+func (d *bucketDestination) commit(dumpName string, checksum string) error {
+
+	return d.Put(dumpName+checksumExt, manifest(checksum, dumpName)+"\n")
+}
+
+func (d *bucketDestination) discard(dumpName string) {
+
+	for _, filename := range []string{dumpName, dumpName + checksumExt} {
+		if err := d.Remove(filename); err != nil {
+			logging.Error(err, logging.Fields{Event: logging.Delete, Dump: filename, Destination: d.String()})
+		}
+	}
+}
+
+// return the dumps of the database in the bucket, leaving out checksum manifests
+func (d *bucketDestination) List(dbName string) ([]Dump, error) {
+
+	objects, err := d.S3.List(dbName + "_-_")
+	if err != nil {
+		return nil, err
+	}
+
+	var dumps []Dump
+	for _, object := range objects {
+		if isDump(object.Key) {
+			dumps = append(dumps, Dump{Name: object.Key, Size: object.Size, ModTime: object.LastModified})
+		}
+	}
+	return dumps, nil
+}
+
+func (d *bucketDestination) Delete(filenames []string) error {
+
+	for _, filename := range filenames {
+		if err := d.Remove(filename); err != nil {
+			return err
+		}
+		if err := d.Remove(filename + checksumExt); err != nil {
+			return err
+		}
+		logging.Info("deleted db dump: "+filename+" from "+d.String(), logging.Fields{Event: logging.Delete, DB: dbNameOf(filename), Dump: filename, Destination: d.String()})
+	}
+	return nil
+}
+
+// a dump, plain or compressed and possibly encrypted, as opposed to a manifest or anything else sharing the bucket
+func isDump(filename string) bool {
+
+	filename = strings.TrimSuffix(filename, ".age")
+	for _, ext := range dumpExts {
+		if strings.HasSuffix(filename, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/inet"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// a local S3 stand-in, just enough of the API for a bucket destination: objects, multipart uploads and listing
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]string
+	uploads map[string]map[int]string
+	nextID  int
+}
+
+func newFakeS3(t *testing.T, bucket string) (*fakeS3, *inet.S3) {
+
+	fake := &fakeS3{bucket: bucket, objects: make(map[string]string), uploads: make(map[string]map[int]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s3 := new(inet.S3)
+	s3.Make(server.URL, "", "accessKey", "secretKey", bucket, "tto")
+	if err := s3.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return fake, s3
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path != f.bucket && !strings.HasPrefix(path, f.bucket+"/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(path, f.bucket), "/")
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodHead && key == "":
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodGet && key == "":
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, query.Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w, `<ListBucketResult><Name>%s</Name><KeyCount>%d</KeyCount><MaxKeys>1000</MaxKeys><IsTruncated>false</IsTruncated>`, f.bucket, len(keys))
+		for _, k := range keys {
+			fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>%d</Size><LastModified>2019-08-02T12:00:00.000Z</LastModified><ETag>"etag"</ETag></Contents>`, k, len(f.objects[k]))
+		}
+		fmt.Fprint(w, `</ListBucketResult>`)
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.uploads[id] = make(map[int]string)
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, f.bucket, key, id)
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		part, _ := strconv.Atoi(query.Get("partNumber"))
+		f.uploads[query.Get("uploadId")][part] = readBody(r)
+		w.Header().Set("ETag", `"etag"`)
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts := f.uploads[query.Get("uploadId")]
+		var object strings.Builder
+		for i := 1; i <= len(parts); i++ {
+			object.WriteString(parts[i])
+		}
+		f.objects[key] = object.String()
+		delete(f.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`, f.bucket, key)
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		f.objects[key] = readBody(r)
+		w.Header().Set("ETag", `"etag"`)
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// the body of an upload, without the chunk signatures of the streaming signature used over plain http
+func readBody(r *http.Request) string {
+
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		body, _ := ioutil.ReadAll(r.Body)
+		return string(body)
+	}
+
+	var body strings.Builder
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return body.String()
+		}
+		size, err := strconv.ParseInt(strings.SplitN(strings.TrimSpace(header), ";", 2)[0], 16, 64)
+		if err != nil || size == 0 {
+			return body.String()
+		}
+		if _, err = io.CopyN(&body, reader, size); err != nil {
+			return body.String()
+		}
+		_, _ = reader.ReadString('\n')
+	}
+}
+
+// an exec handler whose dump process has already exited successfully
+func newExitedExec(t *testing.T) *exec.Exec {
+
+	exe := new(exec.Exec)
+	exe.LocalCmdOnly([]string{"true"})
+	if err := exe.Cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return exe
+}
+
+func TestBucketDestination(t *testing.T) {
+
+	fake, s3 := newFakeS3(t, "backups")
+	dest := NewBucketDestination(s3)
+	fake.objects["tto/other_-_20190802120000.sql"] = "another database"
+
+	stdout := ioutil.NopCloser(strings.NewReader(testDump))
+	errs := ToRemote([]Destination{dest}, testDumpName, &stdout, newExitedExec(t), "none", nil, false)
+	if errs[0] != nil {
+		t.Fatalf("Bucket destination test failed; found, expected: %#v, %s", errs[0], "nil err")
+	}
+
+	if fake.objects["tto/"+testDumpName] != testDump {
+		t.Errorf("Bucket destination test failed; found, expected: %q, %q", fake.objects["tto/"+testDumpName], testDump)
+	}
+	hr := newHashingReader(ioutil.NopCloser(strings.NewReader(testDump)))
+	_, _ = io.Copy(ioutil.Discard, hr)
+	if fake.objects["tto/"+testDumpName+checksumExt] != manifest(hr.Sum(), testDumpName)+"\n" {
+		t.Errorf("Bucket destination test failed; found, expected: %q, %q", fake.objects["tto/"+testDumpName+checksumExt], manifest(hr.Sum(), testDumpName))
+	}
+
+	// only dumps of the database are listed, not their manifests
+	dumps, err := dest.List("databaseName")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dumps) != 1 || dumps[0].Name != testDumpName || dumps[0].Size != int64(len(testDump)) {
+		t.Errorf("Bucket destination list test failed; found, expected: %v, %v", dumps, []string{testDumpName})
+	}
+
+	if err = dest.Delete([]string{dumps[0].Name}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.objects) != 1 {
+		t.Errorf("Bucket destination delete test failed; found, expected: %d, %d objects", len(fake.objects), 1)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("mysqldump: Got error: 2013: Lost connection to MySQL server")
+}
+
+func TestBucketDestination_FailedDump(t *testing.T) {
+
+	fake, s3 := newFakeS3(t, "backups")
+	dest := NewBucketDestination(s3)
+
+	stdout := ioutil.NopCloser(io.MultiReader(strings.NewReader(testDump), failingReader{}))
+	errs := ToRemote([]Destination{dest}, testDumpName, &stdout, newExitedExec(t), "none", nil, false)
+	if errs[0] == nil {
+		t.Fatalf("Bucket destination failed dump test failed; found, expected: %#v, %s", errs[0], "not nil err")
+	}
+
+	// a failed dump leaves nothing behind, not even an incomplete upload
+	if len(fake.objects) != 0 || len(fake.uploads) != 0 {
+		t.Errorf("Bucket destination failed dump test failed; found, expected: %d objects %d uploads, %s", len(fake.objects), len(fake.uploads), "none")
+	}
+}
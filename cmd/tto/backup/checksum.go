@@ -0,0 +1,91 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// the sidecar manifest is stored next to the dump, in sha256sum format. e.g. databaseName_-_20190802120000.sql.sha256
+const checksumExt = ".sha256"
+
+// hashingReader computes the SHA-256 and size of everything read through it
+type hashingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+	size int64
+}
+
+func newHashingReader(r io.ReadCloser) *hashingReader {
+	return &hashingReader{ReadCloser: r, hash: sha256.New()}
+}
+
+func (hr *hashingReader) Read(p []byte) (int, error) {
+	n, err := hr.ReadCloser.Read(p)
+	hr.hash.Write(p[:n])
+	hr.size += int64(n)
+	return n, err
+}
+
+// return the hex encoded checksum of the bytes read so far
+func (hr *hashingReader) Sum() string {
+	return hex.EncodeToString(hr.hash.Sum(nil))
+}
+
+// manifest line, compatible with `sha256sum -c`
+func manifest(checksum string, dumpName string) string {
+	return checksum + "  " + dumpName
+}
+
+// verifyChecksum compares the dump against its sidecar manifest. The manifest is written before the dump becomes
+// visible, so a missing one is an error. Dumps transferred before manifests existed have none, allowMissing restores
+// them with a warning
+func verifyChecksum(workingDir string, dumpName string, allowMissing bool) error {
+
+	contents, err := ioutil.ReadFile(workingDir + dumpName + checksumExt)
+	if os.IsNotExist(err) {
+		if !allowMissing {
+			return errors.New("no checksum manifest for " + dumpName + ". Refusing to restore an unverified dump, set allow_missing_checksum for dumps from before manifests")
+		}
+		logging.Warning("no checksum manifest for "+dumpName+", skipping integrity check", logging.Fields{Event: logging.Restore, DB: dbNameOf(dumpName), Dump: dumpName})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(string(contents))
+	if len(fields) != 2 || fields[1] != dumpName {
+		return errors.New("malformed checksum manifest: " + dumpName + checksumExt)
+	}
+
+	fd, err := os.Open(workingDir + dumpName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := fd.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, fd); err != nil {
+		return err
+	}
+
+	if found := hex.EncodeToString(h.Sum(nil)); found != fields[0] {
+		return errors.New("checksum mismatch for " + dumpName + ": expected " + fields[0] + ", found " + found + ". Refusing to restore a corrupt or truncated dump")
+	}
+
+	return nil
+}
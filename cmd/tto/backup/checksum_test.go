@@ -0,0 +1,92 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const testDumpName = "databaseName_-_20190802120000.sql"
+const testDump = "CREATE TABLE t (id int);\nINSERT INTO t VALUES (1);\n-- Dump completed\n"
+
+// write a dump and its manifest, as the sender would, into a temp working dir
+func newTestWorkingDir(t *testing.T, dump string) string {
+
+	workingDir := t.TempDir() + "/"
+
+	hr := newHashingReader(ioutil.NopCloser(strings.NewReader(dump)))
+	if _, err := io.Copy(ioutil.Discard, hr); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(workingDir+testDumpName, []byte(dump), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(workingDir+testDumpName+checksumExt, []byte(manifest(hr.Sum(), testDumpName)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return workingDir
+}
+
+func TestHashingReader_Sum(t *testing.T) {
+
+	hr := newHashingReader(ioutil.NopCloser(strings.NewReader("abc")))
+	if _, err := io.Copy(ioutil.Discard, hr); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if hr.Sum() != expected {
+		t.Errorf("Hashing reader test failed; found, expected: %s, %s", hr.Sum(), expected)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+
+	workingDir := newTestWorkingDir(t, testDump)
+	if err := verifyChecksum(workingDir, testDumpName, false); err != nil {
+		t.Errorf("Verify checksum test failed; found, expected: %#v, %s", err, "nil err")
+	}
+}
+
+func TestVerifyChecksum_Truncated(t *testing.T) {
+
+	workingDir := newTestWorkingDir(t, testDump)
+
+	// simulate a transfer that was cut short
+	if err := ioutil.WriteFile(workingDir+testDumpName, []byte(testDump[:20]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyChecksum(workingDir, testDumpName, false); err == nil {
+		t.Errorf("Verify checksum truncated test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}
+
+func TestVerifyChecksum_Malformed(t *testing.T) {
+
+	workingDir := newTestWorkingDir(t, testDump)
+
+	if err := ioutil.WriteFile(workingDir+testDumpName+checksumExt, []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyChecksum(workingDir, testDumpName, false); err == nil {
+		t.Errorf("Verify checksum malformed test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}
+
+func TestVerifyChecksum_NoManifest(t *testing.T) {
+
+	workingDir := t.TempDir() + "/"
+	if err := ioutil.WriteFile(workingDir+testDumpName, []byte(testDump), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyChecksum(workingDir, testDumpName, false); err == nil {
+		t.Errorf("Verify checksum no manifest test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	if err := verifyChecksum(workingDir, testDumpName, true); err != nil {
+		t.Errorf("Verify checksum allow missing test failed; found, expected: %#v, %s", err, "nil err")
+	}
+}
@@ -0,0 +1,57 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/inet"
+	"io"
+	"time"
+)
+
+// dumps are plain or compressed, any of them may be encrypted on top
+var dumpExts = []string{".sql", ".sql.gz", ".sql.zst"}
+
+// Dump is a dump stored at a destination
+type Dump struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Destination is where dumps are stored, a tto receiver reached over ssh, an S3-compatible bucket or a local directory
+type Destination interface {
+	Connect() error
+	TestConnection() error
+	Reconnect(tries int, delayInSec int) error
+
+	// List returns the dumps of the database stored at the destination
+	List(dbName string) ([]Dump, error)
+
+	// Delete removes dumps along with their checksum manifests
+	Delete(filenames []string) error
+
+	String() string
+
+	// the stages of ToRemote: store streams the dump, commit makes it visible as the latest dump along with its
+	// checksum and discard removes whatever is left of a failed dump
+	store(dumpName string, reader io.ReadCloser, ex *exec.Exec) error
+	commit(dumpName string, checksum string) error
+	discard(dumpName string)
+}
+
+// NewSSHDestination returns a tto receiver as destination. transfer selects how dumps are streamed, sftp or scp
+func NewSSHDestination(sh *inet.SSH, exe *exec.Exec, workingDir string, transfer string) Destination {
+	return &sshDestination{SSH: sh, exe: exe, workingDir: workingDir, transfer: transfer}
+}
+
+// NewBucketDestination returns an S3-compatible bucket as destination
+func NewBucketDestination(s3 *inet.S3) Destination {
+	return &bucketDestination{S3: s3}
+}
+
+// NewDirectoryDestination returns a local directory as destination
+func NewDirectoryDestination(dir string) Destination {
+	return &directoryDestination{dir: dir}
+}
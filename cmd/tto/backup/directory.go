@@ -0,0 +1,118 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/netio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a local directory of the sender, e.g. an NFS mount, for a copy on different storage than the receiver's.
+// There is no receiver restoring from it, so there is no .latest.dump. The checksum manifest is stored next to the dump
+type directoryDestination struct {
+	dir string
+}
+
+// a directory needs no connection, but a mount may go away. The directory must exist
+func (d *directoryDestination) Connect() error {
+
+	return d.TestConnection()
+}
+
+func (d *directoryDestination) TestConnection() error {
+
+	info, err := os.Stat(d.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New("not a directory: " + d.dir)
+	}
+
+	return nil
+}
+
+func (d *directoryDestination) Reconnect(tries int, delayInSec int) error {
+
+	for i := 1; i <= tries; i++ {
+		logging.Error(errors.New("["+strconv.Itoa(i)+"/"+strconv.Itoa(tries)+"]"+" checking on "+d.dir), logging.Fields{Event: logging.Connection, Destination: d.dir})
+		if err := d.TestConnection(); err == nil {
+			logging.Info(d.dir+" is back", logging.Fields{Event: logging.Connection, Destination: d.dir})
+			return nil
+		}
+
+		time.Sleep(time.Duration(delayInSec) * time.Second)
+	}
+
+	return errors.New(d.dir + " is still unavailable")
+}
+
+func (d *directoryDestination) String() string {
+
+	return d.dir
+}
+
+// the dump is stored as .part, it's renamed into place once its manifest is written
+func (d *directoryDestination) store(dumpName string, reader io.ReadCloser, ex *exec.Exec) error {
+
+	return netio.StreamFile(&reader, dumpName+".part", d.dir, 0600, ex)
+}
+
+func (d *directoryDestination) commit(dumpName string, checksum string) error {
+
+	if err := ioutil.WriteFile(filepath.Join(d.dir, dumpName+checksumExt), []byte(manifest(checksum, dumpName)+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(d.dir, dumpName+".part"), filepath.Join(d.dir, dumpName))
+}
+
+func (d *directoryDestination) discard(dumpName string) {
+
+	for _, filename := range []string{dumpName, dumpName + ".part", dumpName + checksumExt} {
+		if err := os.Remove(filepath.Join(d.dir, filename)); err != nil && !os.IsNotExist(err) {
+			logging.Error(err, logging.Fields{Event: logging.Delete, Dump: dumpName, Destination: d.dir})
+		}
+	}
+}
+
+// return the dumps of the database in the directory, leaving out checksum manifests and partial dumps
+func (d *directoryDestination) List(dbName string) ([]Dump, error) {
+
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dumps []Dump
+	for _, file := range files {
+		if !file.IsDir() && strings.HasPrefix(file.Name(), dbName+"_-_") && isDump(file.Name()) {
+			dumps = append(dumps, Dump{Name: file.Name(), Size: file.Size(), ModTime: file.ModTime()})
+		}
+	}
+	return dumps, nil
+}
+
+func (d *directoryDestination) Delete(filenames []string) error {
+
+	for _, filename := range filenames {
+		// a dump may have been pruned already
+		if err := os.Remove(filepath.Join(d.dir, filename)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Remove(filepath.Join(d.dir, filename+checksumExt)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		logging.Info("deleted db dump: "+filename+" from "+d.dir, logging.Fields{Event: logging.Delete, DB: dbNameOf(filename), Dump: filename, Destination: d.dir})
+	}
+	return nil
+}
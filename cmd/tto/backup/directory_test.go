@@ -0,0 +1,109 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/metrics"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirectoryDestination(t *testing.T) {
+
+	dir := t.TempDir()
+	dest := NewDirectoryDestination(dir)
+	if err := dest.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "other_-_20190802120000.sql"), []byte("another database"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := ioutil.NopCloser(strings.NewReader(testDump))
+	errs := ToRemote([]Destination{dest}, testDumpName, &stdout, newExitedExec(t), "none", nil, false)
+	if errs[0] != nil {
+		t.Fatalf("Directory destination test failed; found, expected: %#v, %s", errs[0], "nil err")
+	}
+
+	stored, err := ioutil.ReadFile(filepath.Join(dir, testDumpName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != testDump {
+		t.Errorf("Directory destination test failed; found, expected: %q, %q", stored, testDump)
+	}
+	if err = verifyChecksum(dir+"/", testDumpName, false); err != nil {
+		t.Errorf("Directory destination test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	if transferred, _ := metrics.Value(metrics.TransferBytes, "db", "databaseName", "destination", dest.String()); transferred != float64(len(testDump)) {
+		t.Errorf("Directory destination test failed; found, expected: %v, %d bytes transferred", transferred, len(testDump))
+	}
+
+	// only dumps of the database are listed, not their manifests
+	dumps, err := dest.List("databaseName")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dumps) != 1 || dumps[0].Name != testDumpName || dumps[0].Size != int64(len(testDump)) {
+		t.Errorf("Directory destination list test failed; found, expected: %v, %v", dumps, []string{testDumpName})
+	}
+
+	if err = dest.Delete([]string{dumps[0].Name}); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("Directory destination delete test failed; found, expected: %d, %d files", len(files), 1)
+	}
+}
+
+func TestDirectoryDestination_Store(t *testing.T) {
+
+	dir := t.TempDir()
+	dest := NewDirectoryDestination(dir)
+
+	// until it's committed, the dump is only there as .part
+	if err := dest.store(testDumpName, ioutil.NopCloser(strings.NewReader(testDump)), newExitedExec(t)); err != nil {
+		t.Fatalf("Directory destination store test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 || files[0].Name() != testDumpName+".part" {
+		t.Fatalf("Directory destination store test failed; found, expected: %v, %s", files, testDumpName+".part")
+	}
+
+	if err := dest.commit(testDumpName, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, testDumpName)); err != nil {
+		t.Errorf("Directory destination store test failed; found, expected: %#v, %s", err, "nil err")
+	}
+}
+
+func TestDirectoryDestination_FailedDump(t *testing.T) {
+
+	dir := t.TempDir()
+	dest := NewDirectoryDestination(dir)
+
+	stdout := ioutil.NopCloser(io.MultiReader(strings.NewReader(testDump), failingReader{}))
+	errs := ToRemote([]Destination{dest}, testDumpName, &stdout, newExitedExec(t), "none", nil, false)
+	if errs[0] == nil {
+		t.Fatalf("Directory destination failed dump test failed; found, expected: %#v, %s", errs[0], "not nil err")
+	}
+
+	// a failed dump leaves nothing behind, not even a partial file
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("Directory destination failed dump test failed; found, expected: %d, %d files", len(files), 0)
+	}
+
+	// a missing mount is noticed
+	if err := NewDirectoryDestination(filepath.Join(dir, "unmounted")).TestConnection(); !os.IsNotExist(err) {
+		t.Errorf("Directory destination test failed; found, expected: %#v, %s", err, "not exist err")
+	}
+}
@@ -0,0 +1,66 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"os"
+	"strings"
+)
+
+// the receiver tracks the latest dump and restore of each database in its own file,
+// e.g. .latest.dump.databaseName and .latest.restore.databaseName
+
+// LatestDump returns the filename tracking the latest dump of the database
+func LatestDump(dbName string) string {
+	return ".latest.dump." + dbName
+}
+
+// LatestRestore returns the filename tracking the latest restore of the database
+func LatestRestore(dbName string) string {
+	return ".latest.restore." + dbName
+}
+
+// tracking files of releases that restored a single database, before they were tracked per database
+const legacyLatestDump = ".latest.dump"
+const legacyLatestRestore = ".latest.restore"
+
+// MigrateLatest renames the legacy .latest.dump and .latest.restore of the working dir to those of the database, so
+// an upgraded receiver picks up where it left off. Only a receiver of a single database can tell whose they are,
+// with more databases they are left alone. Files already tracked per database are never overwritten
+func MigrateLatest(workingDir string, dbNames []string) error {
+
+	var errs []error
+	for legacy, tracked := range map[string]func(string) string{legacyLatestDump: LatestDump, legacyLatestRestore: LatestRestore} {
+		if _, err := os.Stat(workingDir + legacy); os.IsNotExist(err) {
+			continue
+		}
+		if len(dbNames) != 1 {
+			logging.Warning("ignoring legacy "+workingDir+legacy+", it can't be told which database it tracks", logging.Fields{Event: logging.Config})
+			continue
+		}
+		if info, err := os.Stat(workingDir + tracked(dbNames[0])); err == nil && info.Size() != 0 {
+			logging.Warning("ignoring legacy "+workingDir+legacy+", "+tracked(dbNames[0])+" is already tracked", logging.Fields{Event: logging.Config, DB: dbNames[0]})
+			continue
+		}
+		if err := os.Rename(workingDir+legacy, workingDir+tracked(dbNames[0])); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logging.Info("migrated "+workingDir+legacy+" to "+tracked(dbNames[0]), logging.Fields{Event: logging.Config, DB: dbNames[0]})
+	}
+
+	return errors.Join(errs...)
+}
+
+// restoreLock returns the filename of the lock held while the database is being restored
+func restoreLock(dbName string) string {
+	return "~.restore." + dbName + ".lock"
+}
+
+// dbNameOf returns the database name of a dump filename, e.g. databaseName_-_20190802120000.sql.gz
+func dbNameOf(dumpName string) string {
+	return strings.Split(dumpName, "_-_")[0]
+}
@@ -0,0 +1,45 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestMigrateLatest(t *testing.T) {
+
+	workingDir := t.TempDir() + "/"
+	if err := ioutil.WriteFile(workingDir+legacyLatestDump, []byte(testDumpName+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(workingDir+legacyLatestRestore, []byte(testDumpName+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// created empty by an earlier start of the upgraded receiver
+	if err := ioutil.WriteFile(workingDir+LatestRestore("databaseName"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// with more databases, it can't be told whose they are
+	if err := MigrateLatest(workingDir, []string{"databaseName", "otherName"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(workingDir + legacyLatestDump); err != nil {
+		t.Errorf("Migrate latest test failed; found, expected: %#v, %s", err, "legacy file left alone")
+	}
+
+	if err := MigrateLatest(workingDir, []string{"databaseName"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{LatestDump("databaseName"), LatestRestore("databaseName")} {
+		if found, _ := ioutil.ReadFile(workingDir + file); string(found) != testDumpName+"\n" {
+			t.Errorf("Migrate latest test failed; found, expected: %q, %q", found, testDumpName+"\n")
+		}
+	}
+	if _, err := os.Stat(workingDir + legacyLatestDump); !os.IsNotExist(err) {
+		t.Errorf("Migrate latest test failed; found, expected: %#v, %s", err, "not exist err")
+	}
+}
@@ -7,14 +7,45 @@ import (
 	"bufio"
 	"errors"
 	"github.com/ctomkow/tto/cmd/tto/db"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/metrics"
+	"github.com/ctomkow/tto/cmd/tto/netio"
+	"github.com/ctomkow/tto/cmd/tto/notify"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
-func Restore(dB db.DB, workingDir string) (string, error) {
+// identityFile holds the age private keys for encrypted dumps, it may be empty when dumps aren't encrypted
+// restoreMode "atomic" restores all or nothing through the database driver. Otherwise, restoreEngine "cli" streams the
+// dump into the database's command line client and anything else restores statement by statement through the driver.
+// allowMissingChecksum restores dumps without a checksum manifest, i.e. from before manifests, with a warning
+func Restore(dB db.DB, workingDir string, identityFile string, restoreMode string, restoreEngine string, allowMissingChecksum bool, exe *exec.Exec) (string, error) {
+
+	// failures of the dump itself are notified by RestoreDump, failing to pick it is notified here
+	latestDump, err := pickLatest(dB, workingDir)
+	if err != nil {
+		metrics.Add(metrics.RestoreFailures, 1, "db", dB.Name())
+		notify.Failure(notify.Restore, dB.Name(), "", "", err)
+		return "", err
+	}
+
+	if err = RestoreDump(dB, workingDir, latestDump, identityFile, restoreMode, restoreEngine, allowMissingChecksum, exe); err != nil {
+		return "", err
+	}
+
+	return latestDump, nil
+}
+
+// pickLatest returns the dump in .latest.dump, unless it was restored already
+func pickLatest(dB db.DB, workingDir string) (string, error) {
+
+	latestDumpFile := LatestDump(dB.Name())
+	latestRestoreFile := LatestRestore(dB.Name())
 
 	// ## .latest.dump actions
 
@@ -22,7 +53,7 @@ func Restore(dB db.DB, workingDir string) (string, error) {
 	// retries 3 times with a 3 second sleep inbetween. Used for unfortunate timings...
 	retryCount := 0
 	for {
-		if fileExists(workingDir + "~.latest.dump.lock") {
+		if fileExists(workingDir + "~" + latestDumpFile + ".lock") {
 			retryCount++
 			time.Sleep(3 * time.Second)
 		} else {
@@ -30,18 +61,18 @@ func Restore(dB db.DB, workingDir string) (string, error) {
 		}
 
 		if retryCount == 3 {
-			return "", errors.New("locked: .latest.dump is being used by another process, or lock file is stuck. Suggest manually removing ~.latest.dump.lock")
+			return "", errors.New("locked: " + latestDumpFile + " is being used by another process, or lock file is stuck. Suggest manually removing ~" + latestDumpFile + ".lock")
 		}
 	}
 
 	// create ~.latest.dump.lock
-	_, err := os.Create(workingDir + "~.latest.dump.lock")
+	_, err := os.Create(workingDir + "~" + latestDumpFile + ".lock")
 	if err != nil {
 		return "", err
 	}
 
 	// open .latest.dump and read first line
-	dumpFile, err := os.Open(workingDir + ".latest.dump")
+	dumpFile, err := os.Open(workingDir + latestDumpFile)
 	if err != nil {
 		return "", err
 	}
@@ -54,12 +85,12 @@ func Restore(dB db.DB, workingDir string) (string, error) {
 	}
 
 	// delete ~.latest.dump.lock
-	if err = os.Remove(workingDir + "~.latest.dump.lock"); err != nil {
+	if err = os.Remove(workingDir + "~" + latestDumpFile + ".lock"); err != nil {
 		return "", err
 	}
 
 	// ## safety check: latest dump vs configuration database name
-	if strings.Compare(strings.Split(latestDump, "_-_")[0], dB.Name()) != 0 {
+	if strings.Compare(dbNameOf(latestDump), dB.Name()) != 0 {
 		// oh shit, someone is dumping one database but trying to restoreDatabase it into another one
 		return "", errors.New("the dumped database does not match the one configured in the conf file")
 	}
@@ -67,7 +98,7 @@ func Restore(dB db.DB, workingDir string) (string, error) {
 	// ## .latest.restore actions
 
 	// open .latest.restore and read first line
-	restoreFile, err := os.Open(workingDir + ".latest.restore")
+	restoreFile, err := os.Open(workingDir + latestRestoreFile)
 	if err != nil {
 		return "", err
 	}
@@ -80,31 +111,115 @@ func Restore(dB db.DB, workingDir string) (string, error) {
 
 	// if dump and restoreDatabase the same, then return error
 	if strings.Compare(latestDump, latestRestore) == 0 {
-		return "", errors.New(".latest.dump and .latest.restore are the same")
+		return "", errors.New(latestDumpFile + " and " + latestRestoreFile + " are the same")
+	}
+
+	return latestDump, nil
+}
+
+// RestoreDump restores the given dump of the working dir into the database, e.g. an older one picked by hand, and
+// records it in .latest.restore. Same modes and engines as Restore
+func RestoreDump(dB db.DB, workingDir string, dumpName string, identityFile string, restoreMode string, restoreEngine string, allowMissingChecksum bool, exe *exec.Exec) error {
+
+	start := time.Now()
+	if err := restoreDump(dB, workingDir, dumpName, identityFile, restoreMode, restoreEngine, allowMissingChecksum, exe); err != nil {
+		metrics.Add(metrics.RestoreFailures, 1, "db", dB.Name())
+		notify.Failure(notify.Restore, dB.Name(), "", dumpName, err)
+		return err
+	}
+	metrics.Set(metrics.RestoreLastSuccess, float64(time.Now().Unix()), "db", dB.Name())
+	metrics.Set(metrics.RestoreDuration, time.Since(start).Seconds(), "db", dB.Name())
+	logging.Info("restored db dump: "+dumpName, logging.Fields{Event: logging.Restore, DB: dB.Name(), Dump: dumpName, Duration: time.Since(start)})
+	notify.Success(notify.Restore, dB.Name(), "", dumpName)
+
+	return nil
+}
+
+func restoreDump(dB db.DB, workingDir string, dumpName string, identityFile string, restoreMode string, restoreEngine string, allowMissingChecksum bool, exe *exec.Exec) error {
+
+	latestRestoreFile := LatestRestore(dB.Name())
+
+	if strings.Compare(dbNameOf(dumpName), dB.Name()) != 0 {
+		return errors.New("the dump " + dumpName + " is not of database " + dB.Name())
+	}
+
+	// refuse to restore a dump that doesn't match what the sender produced
+	if err := verifyChecksum(workingDir, dumpName, allowMissingChecksum); err != nil {
+		return err
 	}
 
 	// restore database dump into database
-	fd, err := os.Open(workingDir+latestDump)
+	plain, err := openDecoded(workingDir+dumpName, identityFile)
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer func() {
-		if err := fd.Close(); err != nil {
-			glog.Error(err)
+		if err := plain.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
 		}
 	}()
 
-	dumpReader := bufio.NewReader(fd)
-	if err = dB.Restore(dumpReader); err != nil {
-		return "", err
+	dumpReader := bufio.NewReader(plain)
+	switch {
+	case restoreMode == "atomic":
+		err = dB.RestoreAtomic(dumpReader)
+	case restoreEngine == "cli":
+		err = dB.RestoreCli(exe, dumpReader)
+	default:
+		err = dB.Restore(dumpReader)
+	}
+	if err != nil {
+		return err
 	}
 
 	// update .latest.restore with restored dump filename
-	if err = ioutil.WriteFile(workingDir+".latest.restore", []byte(latestDump), 0600); err != nil {
-		return "", err
+	if err = ioutil.WriteFile(workingDir+latestRestoreFile, []byte(dumpName), 0600); err != nil {
+		return err
 	}
 
-	return latestDump, nil
+	return nil
+}
+
+// a decrypted and decompressed file, closing it closes the file underneath
+type decodedFile struct {
+	io.Reader
+	plain io.Closer
+	fd    *os.File
+}
+
+func (df *decodedFile) Close() error {
+	err := df.plain.Close()
+	if fdErr := df.fd.Close(); err == nil {
+		err = fdErr
+	}
+	return err
+}
+
+// openDecoded opens an encrypted and compressed dump, decrypted and decompressed on the fly based on its extensions
+func openDecoded(path string, identityFile string) (io.ReadCloser, error) {
+
+	return openDecodedWith(path, identityFile, netio.Decompress)
+}
+
+// openDecodedWith opens an encrypted and compressed file like openDecoded, decompressed by the given func
+func openDecodedWith(path string, identityFile string, decompress func(io.Reader, string) (io.ReadCloser, error)) (io.ReadCloser, error) {
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	decrypted, plainName, err := netio.Decrypt(bufio.NewReader(fd), filepath.Base(path), identityFile)
+	if err != nil {
+		_ = fd.Close()
+		return nil, err
+	}
+	plain, err := decompress(decrypted, plainName)
+	if err != nil {
+		_ = fd.Close()
+		return nil, err
+	}
+
+	return &decodedFile{Reader: plain, plain: plain, fd: fd}, nil
 }
 
 func fileExists(filename string) bool {
@@ -115,3 +230,39 @@ func fileExists(filename string) bool {
 	}
 	return !info.IsDir()
 }
+
+// LockRestore takes the restore lock of the database in the working dir, so the receiver and an on-demand restore
+// never restore into the same database at once. The returned func releases it
+func LockRestore(workingDir string, dbName string) (func(), error) {
+
+	lockFile := workingDir + restoreLock(dbName)
+	fd, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		return nil, errors.New("locked: " + dbName + " is being restored by another process, or lock file is stuck. Suggest manually removing " + restoreLock(dbName))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = fd.Close(); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := os.Remove(lockFile); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}, nil
+}
+
+// RestoreLocked reports whether the database is being restored, by the receiver or an on-demand restore
+func RestoreLocked(workingDir string, dbName string) bool {
+
+	_, err := os.Stat(workingDir + restoreLock(dbName))
+	return err == nil
+}
+
+// ListDumps returns the dumps of the database in the working dir of the receiver
+func ListDumps(workingDir string, dbName string) ([]Dump, error) {
+
+	return (&directoryDestination{dir: workingDir}).List(dbName)
+}
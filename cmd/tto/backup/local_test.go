@@ -0,0 +1,42 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"testing"
+)
+
+func TestLockRestore(t *testing.T) {
+
+	dir := t.TempDir() + "/"
+
+	release, err := LockRestore(dir, "databaseName")
+	if err != nil {
+		t.Fatalf("Restore lock test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if !RestoreLocked(dir, "databaseName") || RestoreLocked(dir, "otherName") {
+		t.Errorf("Restore lock test failed; found, expected: %t %t, %t %t", RestoreLocked(dir, "databaseName"), RestoreLocked(dir, "otherName"), true, false)
+	}
+
+	// a second restore of the same database is refused, another database isn't
+	if _, err = LockRestore(dir, "databaseName"); err == nil {
+		t.Errorf("Restore lock test failed; found, expected: %#v, %s", err, "locked err")
+	}
+	releaseOther, err := LockRestore(dir, "otherName")
+	if err != nil {
+		t.Errorf("Restore lock test failed; found, expected: %#v, %s", err, "nil err")
+	} else {
+		releaseOther()
+	}
+
+	release()
+	if RestoreLocked(dir, "databaseName") {
+		t.Errorf("Restore lock test failed; found, expected: %t, %t", true, false)
+	}
+	if release, err = LockRestore(dir, "databaseName"); err != nil {
+		t.Errorf("Restore lock test failed; found, expected: %#v, %s", err, "nil err")
+	} else {
+		release()
+	}
+}
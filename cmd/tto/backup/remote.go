@@ -4,60 +4,237 @@
 package backup
 
 import (
+	"errors"
 	"github.com/ctomkow/tto/cmd/tto/exec"
 	"github.com/ctomkow/tto/cmd/tto/inet"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/metrics"
 	"github.com/ctomkow/tto/cmd/tto/netio"
-	"github.com/golang/glog"
 	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-// add lock file, copy dump over, remove lock, add lock for .latest.dump, update .latest.dump, remove lock
-func ToRemote(sh *inet.SSH, workingDir string, dumpName string, stdout *io.ReadCloser, ex *exec.Exec) error {
+// ToRemote streams the dump to all destinations at once. It is compressed, encrypted and checksummed a single time
+// and teed to every destination, see Destination for what happens at each of them
+// compression (gzip, zstd) is applied before the stream leaves the host, followed by encryption to the age recipients.
+// dumpName is expected to carry the matching extensions
+// With requireAll, one failed destination fails the dump on all of them. Otherwise a destination that fails drops out
+// while the others carry on. Wherever the dump fails, it is removed again.
+// The returned errors match the destinations, nil where the dump was stored
+func ToRemote(dests []Destination, dumpName string, stdout *io.ReadCloser, ex *exec.Exec, compression string, recipients []string, requireAll bool) []error {
 
-	_, err := ex.RemoteCmd(sh, "touch "+workingDir+"~"+dumpName+".lock")
+	errs := make([]error, len(dests))
+
+	var encrypted io.ReadCloser
+	compressed, err := netio.Compress(*stdout, compression)
+	if err == nil {
+		encrypted, err = netio.Encrypt(compressed, recipients)
+	}
+	if err != nil {
+		ex.Kill()
+		for i := range errs {
+			errs[i] = errors.New("failed to transfer db dump " + dumpName + ": " + err.Error())
+		}
+		return errs
+	}
+
+	// checksum the dump, as it is stored at the destinations, while it streams through
+	hashed := newHashingReader(encrypted)
+	readers := netio.Tee(hashed, len(dests), requireAll)
+
+	start := time.Now()
+	durations := make([]time.Duration, len(dests))
+
+	wg := sync.WaitGroup{}
+	for i := range dests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = dests[i].store(dumpName, readers[i], ex)
+			durations[i] = time.Since(start)
+			if errs[i] != nil {
+				// stop receiving the stream, so the others aren't held up
+				_ = readers[i].Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(dests) || (requireAll && failed > 0) {
+		// don't leave the dump process hanging on a pipe nobody reads anymore
+		ex.Kill()
+		_ = hashed.Close()
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = errors.New("another destination failed")
+			}
+		}
+	}
+
+	for i, dest := range dests {
+		labels := []string{"db", dbNameOf(dumpName), "destination", dest.String()}
+		if errs[i] != nil {
+			dest.discard(dumpName)
+			errs[i] = errors.New("failed to transfer db dump " + dumpName + " to " + dest.String() + ": " + errs[i].Error())
+			metrics.Add(metrics.TransferFailures, 1, labels...)
+			continue
+		}
+		if errs[i] = dest.commit(dumpName, hashed.Sum()); errs[i] != nil {
+			metrics.Add(metrics.TransferFailures, 1, labels...)
+			continue
+		}
+		logging.Info("transferred db dump: "+dumpName+" to "+dest.String()+" sha256: "+hashed.Sum(),
+			logging.Fields{Event: logging.Transfer, DB: dbNameOf(dumpName), Dump: dumpName, Destination: dest.String(), Bytes: hashed.size, Duration: durations[i]})
+		metrics.Set(metrics.TransferLastSuccess, float64(time.Now().Unix()), labels...)
+		metrics.Set(metrics.TransferDuration, durations[i].Seconds(), labels...)
+		metrics.Add(metrics.TransferBytes, float64(hashed.size), labels...)
+	}
+
+	return errs
+}
+
+// a tto receiver, reached over ssh. The dump is written to the working directory of the receiver:
+// add lock file, copy dump over as .part, write checksum manifest, rename the dump into place, remove lock, add lock for
+// .latest.dump, update .latest.dump, remove lock. A dump is never visible without its manifest. .latest.dump is tracked per database, the database name is taken from dumpName
+type sshDestination struct {
+	*inet.SSH
+	exe        *exec.Exec
+	workingDir string
+	transfer   string
+}
+
+// stream the dump to the receiver as .part, under a lock file. transfer selects how, sftp (default) or scp
+func (d *sshDestination) store(dumpName string, reader io.ReadCloser, ex *exec.Exec) error {
+
+	_, err := d.exe.RemoteCmd(d.SSH, "touch "+d.workingDir+"~"+dumpName+".lock")
+	if err != nil {
+		return err
+	}
+
+	switch d.transfer {
+	case "scp":
+		err = netio.StreamMySqlDump(&reader, dumpName+".part", d.workingDir, "0600", ex, d.SSH)
+	default:
+		err = netio.StreamSftp(&reader, dumpName+".part", d.workingDir, 0600, ex, d.SSH)
+	}
 	if err != nil {
 		return err
 	}
-	if err = netio.StreamMySqlDump(stdout, dumpName, workingDir, "0600", ex, sh); err != nil {
+
+	// the dump process must have exited cleanly, even if the transfer didn't notice
+	return ex.Wait()
+}
+
+// write the checksum manifest, rename the dump into place, release the dump lock and point .latest.dump at the dump
+func (d *sshDestination) commit(dumpName string, checksum string) error {
+
+	_, err := d.exe.RemoteCmd(d.SSH, "echo '"+manifest(checksum, dumpName)+"' > "+d.workingDir+dumpName+checksumExt)
+	if err != nil {
+		return err
+	}
+	_, err = d.exe.RemoteCmd(d.SSH, "mv -f "+d.workingDir+dumpName+".part "+d.workingDir+dumpName)
+	if err != nil {
 		return err
 	}
-	_, err = ex.RemoteCmd(sh, "rm "+workingDir+"~"+dumpName+".lock")
+	_, err = d.exe.RemoteCmd(d.SSH, "rm "+d.workingDir+"~"+dumpName+".lock")
 	if err != nil {
 		return err
 	}
-	_, err = ex.RemoteCmd(sh, "touch "+workingDir+"~.latest.dump.lock")
+	latestDump := LatestDump(dbNameOf(dumpName))
+	_, err = d.exe.RemoteCmd(d.SSH, "touch "+d.workingDir+"~"+latestDump+".lock")
 	if err != nil {
 		return err
 	}
-	_, err = ex.RemoteCmd(sh, "echo "+dumpName+" > "+workingDir+".latest.dump")
+	_, err = d.exe.RemoteCmd(d.SSH, "echo "+dumpName+" > "+d.workingDir+latestDump)
 	if err != nil {
 		return err
 	}
-	_, err = ex.RemoteCmd(sh, "rm "+workingDir+"~.latest.dump.lock")
+	_, err = d.exe.RemoteCmd(d.SSH, "rm "+d.workingDir+"~"+latestDump+".lock")
 	if err != nil {
 		return err
 	}
-	glog.Info("transferred db dump: " + dumpName)
 	return nil
 }
 
-// Retrieve returns a multiline string of database dumps that is delimited based on the remote host's operating system
-func Retrieve(sh *inet.SSH, exe *exec.Exec, dbName string, workingDir string) (string, error) {
-	result, err := exe.RemoteCmd(sh, "find "+workingDir+" -name *'"+dbName+"*.sql'")
+// remove a failed dump, its manifest and lock. .latest.dump is left untouched
+func (d *sshDestination) discard(dumpName string) {
+
+	if _, err := d.exe.RemoteCmd(d.SSH, "rm -f "+d.workingDir+dumpName+" "+d.workingDir+dumpName+".part "+d.workingDir+dumpName+checksumExt+" "+d.workingDir+"~"+dumpName+".lock"); err != nil {
+		logging.Error(err, logging.Fields{Event: logging.Transfer, DB: dbNameOf(dumpName), Dump: dumpName, Destination: d.String()})
+	}
+}
+
+// return the dumps of the database in the working directory of the receiver
+func (d *sshDestination) List(dbName string) ([]Dump, error) {
+
+	return Retrieve(d.SSH, d.exe, dbName, d.workingDir)
+}
+
+func (d *sshDestination) Delete(filenames []string) error {
+
+	return Delete(d.SSH, d.exe, d.workingDir, filenames)
+}
+
+// Retrieve returns the database dumps in the working directory of the remote host, along with their sizes and mtimes.
+// Only dumps of dbName are matched, other databases may share the working directory
+func Retrieve(sh *inet.SSH, exe *exec.Exec, dbName string, workingDir string) ([]Dump, error) {
+	var patterns []string
+	for _, ext := range dumpExts {
+		patterns = append(patterns, "-name '"+dbName+"_-_*"+ext+"'", "-name '"+dbName+"_-_*"+ext+".age'")
+	}
+	result, err := exe.RemoteCmd(sh, "find "+workingDir+" -maxdepth 1 -type f \\( "+strings.Join(patterns, " -o ")+" \\) -printf '"+findFormat+"'")
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	return parseFind(result)
+}
+
+// filename, size in bytes and mtime in seconds since the epoch, tab separated
+const findFormat = "%f\\t%s\\t%T@\\n"
+
+// parse the output of find with findFormat
+func parseFind(output string) ([]Dump, error) {
+
+	var dumps []Dump
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, errors.New("unexpected find output: " + line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		seconds, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		if err != nil {
+			return nil, err
+		}
+		dumps = append(dumps, Dump{Name: fields[0], Size: size, ModTime: time.Unix(0, int64(seconds*float64(time.Second)))})
 	}
-	return result, nil
+	return dumps, nil
 }
 
 // Delete removes files from a remote host
 func Delete(sh *inet.SSH, exe *exec.Exec, workingDir string, filenames []string) error {
 	for _, filename := range filenames {
-		_, err := exe.RemoteCmd(sh, "rm "+workingDir+filename)
+		// a dump may have been pruned already
+		_, err := exe.RemoteCmd(sh, "rm -f "+workingDir+filename+" "+workingDir+filename+checksumExt)
 		if err != nil {
 			return err
 		}
-		glog.Info("deleted db dump: " + filename)
+		logging.Info("deleted db dump: "+filename, logging.Fields{Event: logging.Delete, DB: dbNameOf(filename), Dump: filename, Destination: sh.String()})
 	}
 	return nil
 }
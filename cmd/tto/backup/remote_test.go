@@ -0,0 +1,192 @@
+// Craig Tomkow
+// October 18, 2026
+
+package backup
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/inet"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"io/ioutil"
+	"net"
+	osexec "os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a local stand-in for a tto receiver: an ssh server that runs commands with sh and serves sftp, on the local
+// filesystem. Returns a connected client and the working directory
+func newFakeReceiver(t *testing.T) (*inet.SSH, string) {
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeReceiver(conn, config)
+		}
+	}()
+
+	// the client authenticates with a passphrase protected key, like a sender does
+	_, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(clientKey, "", []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sh := new(inet.SSH)
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	if err = sh.Make("127.0.0.1", port, "tto", "passphrase", keyFile, filepath.Join(t.TempDir(), "known_hosts"), "", true); err != nil {
+		t.Fatal(err)
+	}
+	if err = sh.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sh.CloseConnection() })
+
+	return sh, t.TempDir() + "/"
+}
+
+func serveFakeReceiver(conn net.Conn, config *ssh.ServerConfig) {
+
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "only sessions")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go serveFakeSession(channel, requests)
+	}
+}
+
+func serveFakeSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+
+	for req := range requests {
+		switch req.Type {
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			go func() {
+				cmd := osexec.Command("sh", "-c", payload.Command)
+				cmd.Stdin, cmd.Stdout, cmd.Stderr = channel, channel, channel.Stderr()
+				status := 0
+				if err := cmd.Run(); err != nil {
+					status = 1
+					var exitErr *osexec.ExitError
+					if errors.As(err, &exitErr) {
+						status = exitErr.ExitCode()
+					}
+				}
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+				_ = channel.Close()
+			}()
+		case "subsystem":
+			_ = req.Reply(true, nil)
+			go func() {
+				server, err := sftp.NewServer(channel)
+				if err == nil {
+					_ = server.Serve()
+				}
+				_ = channel.Close()
+			}()
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+func TestSshDestination_ScpChecksum(t *testing.T) {
+
+	sh, workingDir := newFakeReceiver(t)
+	dest := NewSSHDestination(sh, new(exec.Exec), workingDir, "scp")
+
+	// compressed, the dump has to arrive byte for byte for its checksum to match
+	dumpName := testDumpName + ".gz"
+	stdout := ioutil.NopCloser(strings.NewReader(testDump))
+	errs := ToRemote([]Destination{dest}, dumpName, &stdout, newExitedExec(t), "gzip", nil, false)
+	if errs[0] != nil {
+		t.Fatalf("Ssh destination scp checksum test failed; found, expected: %#v, %s", errs[0], "nil err")
+	}
+
+	if err := verifyChecksum(workingDir, dumpName, false); err != nil {
+		t.Errorf("Ssh destination scp checksum test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if latest, _ := ioutil.ReadFile(workingDir + LatestDump("databaseName")); strings.TrimSpace(string(latest)) != dumpName {
+		t.Errorf("Ssh destination scp checksum test failed; found, expected: %q, %q", latest, dumpName)
+	}
+	files, _ := ioutil.ReadDir(workingDir)
+	if len(files) != 3 {
+		t.Errorf("Ssh destination scp checksum test failed; found, expected: %d, %d files", len(files), 3)
+	}
+}
+
+func TestParseFind(t *testing.T) {
+
+	output := "databaseName_-_20190802120000.sql.gz\t1048576\t1564747200.5000000000\n" +
+		"databaseName_-_20190803120000.sql.gz.age\t42\t1564833600.0000000000\n"
+
+	dumps, err := parseFind(output)
+	if err != nil {
+		t.Fatalf("Parse find test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if len(dumps) != 2 {
+		t.Fatalf("Parse find test failed; found, expected: %d, %d dumps", len(dumps), 2)
+	}
+
+	expected := Dump{Name: "databaseName_-_20190802120000.sql.gz", Size: 1048576, ModTime: time.Date(2019, 8, 2, 12, 0, 0, 500000000, time.UTC)}
+	if dumps[0].Name != expected.Name || dumps[0].Size != expected.Size || !dumps[0].ModTime.Equal(expected.ModTime) {
+		t.Errorf("Parse find test failed; found, expected: %+v, %+v", dumps[0], expected)
+	}
+
+	if dumps, err = parseFind(""); err != nil || len(dumps) != 0 {
+		t.Errorf("Parse find test failed; found, expected: %v %#v, %s", dumps, err, "no dumps")
+	}
+	if _, err = parseFind("databaseName_-_20190802120000.sql\n"); err == nil {
+		t.Errorf("Parse find test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}
@@ -0,0 +1,189 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// the running sender holds a lock on its pid file in the working dir, for backup-now to find it
+const pidFile = ".tto.pid"
+
+// lockPid writes the pid of the sender daemon and locks the file for as long as it runs. A second sender is refused.
+// Returns the release of the lock
+func lockPid(workingDir string) (func(), error) {
+
+	fd, err := os.OpenFile(workingDir+pidFile, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(fd.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = fd.Close()
+		return nil, errors.New("locked: another sender is running, " + workingDir + pidFile + ": " + err.Error())
+	}
+	if err = fd.Truncate(0); err == nil {
+		_, err = fd.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err != nil {
+		_ = fd.Close()
+		return nil, err
+	}
+
+	return func() {
+		if err := os.Remove(workingDir + pidFile); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+		if err := fd.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}, nil
+}
+
+// the running sender records the outcome of a backup now in the working dir, for backup-now to wait on
+const resultFile = ".tto.backup-now"
+
+// signalSender asks the running sender daemon for a backup now, with SIGUSR1. A result of an earlier backup now is
+// removed first, see waitBackupNow. Returns the pid of the daemon, or 0 when no sender is running
+func signalSender(workingDir string) (int, error) {
+
+	pid, err := senderPid(workingDir)
+	if err != nil || pid == 0 {
+		return 0, err
+	}
+
+	if err = os.Remove(workingDir + resultFile); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	return pid, syscall.Kill(pid, syscall.SIGUSR1)
+}
+
+// senderPid returns the pid of the running sender daemon, or 0 when no sender is running. Its pid file is only
+// trusted while the daemon holds the lock on it, a stale pid may belong to another process by now
+func senderPid(workingDir string) (int, error) {
+
+	fd, err := os.Open(workingDir + pidFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := fd.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	err = syscall.Flock(int(fd.Fd()), syscall.LOCK_SH|syscall.LOCK_NB)
+	if err == nil {
+		// nobody holds it, the sender isn't running
+		return 0, syscall.Flock(int(fd.Fd()), syscall.LOCK_UN)
+	}
+	if err != syscall.EWOULDBLOCK {
+		return 0, err
+	}
+
+	contents, err := ioutil.ReadAll(fd)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return 0, errors.New("malformed " + workingDir + pidFile + ": " + err.Error())
+	}
+
+	return pid, nil
+}
+
+// writeBackupNowResult records the outcome of a backup now, failed are the databases whose backup failed.
+// It's written to a temporary name and renamed into place, backup-now never reads half of it
+func writeBackupNowResult(workingDir string, failed []string) error {
+
+	result := "ok\n"
+	if len(failed) != 0 {
+		result = "failed for: " + strings.Join(failed, ", ") + "\n"
+	}
+	if err := ioutil.WriteFile(workingDir+resultFile+".part", []byte(result), 0600); err != nil {
+		return err
+	}
+	return os.Rename(workingDir+resultFile+".part", workingDir+resultFile)
+}
+
+// waitBackupNow waits for the running sender to record the outcome of the backup now it was asked for, checking
+// every interval. Returns an error if any backup failed, or the sender exited before it was done
+func waitBackupNow(workingDir string, interval time.Duration) error {
+
+	for {
+		// the sender is checked first, it may record the outcome and exit in between
+		pid, err := senderPid(workingDir)
+		if err != nil {
+			return err
+		}
+
+		contents, err := ioutil.ReadFile(workingDir + resultFile)
+		if err == nil {
+			if result := strings.TrimSpace(string(contents)); result != "ok" {
+				return errors.New("backup now " + result)
+			}
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		if pid == 0 {
+			return errors.New("the sender exited before the backup now was done")
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// runBackupNow runs a backup of every job of the sender right away, e.g. before a risky migration, when the sender
+// daemon isn't running. Otherwise the daemon is asked with signalSender and waited on with waitBackupNow. Each job goes through the same dump,
+// transfer and retention as on a cron tick. Returns the dumps, or an error if any job failed
+func runBackupNow(conf *conf.Config) ([]string, error) {
+
+	if conf.System.Type != "sender" {
+		return nil, errors.New("backup-now is only available on the sender")
+	}
+
+	jobs, err := newJobs(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var dumps []string
+	var failed []string
+	for _, j := range jobs {
+
+		// a destination that is down is skipped, as the destination policy allows
+		for _, d := range j.destinations {
+			if err := d.trySync(j.conf.DBname); err != nil {
+				return nil, err
+			}
+		}
+
+		dumpName, err := runJob(conf, j)
+		if err != nil {
+			logging.Error(err, logging.Fields{Event: logging.Dump, DB: j.conf.DBname})
+			failed = append(failed, j.conf.DBname)
+			continue
+		}
+		dumps = append(dumps, dumpName)
+	}
+
+	if len(failed) != 0 {
+		return dumps, errors.New("backup now failed for: " + strings.Join(failed, ", "))
+	}
+	return dumps, nil
+}
@@ -0,0 +1,106 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalSender(t *testing.T) {
+
+	workingDir := t.TempDir() + "/"
+
+	// no sender, backup-now runs on its own
+	if pid, err := signalSender(workingDir); pid != 0 || err != nil {
+		t.Errorf("Signal sender test failed; found, expected: %d %#v, %d %s", pid, err, 0, "nil err")
+	}
+
+	release, err := lockPid(workingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lockPid(workingDir); err == nil {
+		t.Errorf("Lock pid test failed; found, expected: %#v, %s", err, "locked err")
+	}
+
+	// this test stands in for the running sender
+	backupNow := make(chan os.Signal, 1)
+	signal.Notify(backupNow, syscall.SIGUSR1)
+	defer signal.Stop(backupNow)
+
+	if pid, err := signalSender(workingDir); pid != os.Getpid() || err != nil {
+		t.Errorf("Signal sender test failed; found, expected: %d %#v, %d %s", pid, err, os.Getpid(), "nil err")
+	}
+	select {
+	case <-backupNow:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Signal sender test failed; found, expected: %s, %s", "no signal", "SIGUSR1")
+	}
+
+	// a stale pid file is left alone
+	release()
+	if err = ioutil.WriteFile(workingDir+pidFile, []byte("1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := signalSender(workingDir); pid != 0 || err != nil {
+		t.Errorf("Signal sender stale test failed; found, expected: %d %#v, %d %s", pid, err, 0, "nil err")
+	}
+}
+
+func TestWaitBackupNow(t *testing.T) {
+
+	workingDir := t.TempDir() + "/"
+	release, err := lockPid(workingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// the outcome of the daemon decides the exit status of backup-now
+	if err = writeBackupNowResult(workingDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = waitBackupNow(workingDir, time.Millisecond); err != nil {
+		t.Errorf("Wait backup now test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if err = writeBackupNowResult(workingDir, []string{"first", "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = waitBackupNow(workingDir, time.Millisecond); err == nil || err.Error() != "backup now failed for: first, second" {
+		t.Errorf("Wait backup now test failed; found, expected: %#v, %s", err, "backup now failed for: first, second")
+	}
+
+	// asking again removes the earlier outcome, backup-now waits for the new one
+	backupNow := make(chan os.Signal, 1)
+	signal.Notify(backupNow, syscall.SIGUSR1)
+	defer signal.Stop(backupNow)
+	if _, err = signalSender(workingDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(workingDir + resultFile); !os.IsNotExist(err) {
+		t.Errorf("Wait backup now test failed; found, expected: %#v, %s", err, "not exist err")
+	}
+	go func() {
+		<-backupNow
+		_ = writeBackupNowResult(workingDir, nil)
+	}()
+	if err = waitBackupNow(workingDir, time.Millisecond); err != nil {
+		t.Errorf("Wait backup now test failed; found, expected: %#v, %s", err, "nil err")
+	}
+}
+
+func TestWaitBackupNow_SenderExited(t *testing.T) {
+
+	workingDir := t.TempDir() + "/"
+
+	// the sender is gone without an outcome
+	if err := waitBackupNow(workingDir, time.Millisecond); err == nil {
+		t.Errorf("Wait backup now exited test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}
@@ -0,0 +1,145 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// shipBinlogs closes the current binary log of the job's database and ships the closed ones a receiver doesn't have
+// yet, oldest first. Binary logs older than the oldest dump at a receiver are deleted, no dump there needs them
+func shipBinlogs(conf *conf.Config, j *job) error {
+
+	if err := j.binlogs.FlushBinlogs(); err != nil {
+		return err
+	}
+	binlogs, err := j.binlogs.ListBinlogs()
+	if err != nil {
+		return err
+	}
+	if len(binlogs) < 2 {
+		return nil
+	}
+	closed := binlogs[:len(binlogs)-1]
+
+	// binary logs are fetched once, for all receivers
+	tmpDir, err := ioutil.TempDir(conf.System.WorkingDir, "~.binlogs.")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+	fetched := make(map[string]string)
+
+	for _, d := range j.destinations {
+		dest, ok := d.remote.(backup.BinlogDestination)
+		if !ok || !d.remoteAlive {
+			continue
+		}
+		shipped, err := dest.ListBinlogs(j.conf.DBname)
+		if err != nil {
+			logging.Error(err, logging.Fields{})
+			continue
+		}
+
+		for _, binlog := range pendingBinlogs(j.conf.DBname, closed, shipped) {
+			path, ok := fetched[binlog]
+			if !ok {
+				if path, err = j.binlogs.FetchBinlog(j.exe, binlog, tmpDir+"/"); err != nil {
+					return err
+				}
+				fetched[binlog] = path
+			}
+			// later binary logs would leave a gap, they are shipped on the next tick
+			if _, err = backup.ShipBinlog(dest, j.conf.DBname, binlog, path, conf.System.Role.Sender.Compression, conf.System.Role.Sender.Recipients); err != nil {
+				logging.Error(err, logging.Fields{})
+				break
+			}
+		}
+
+		d.pruneBinlogs(j.conf.DBname, dest)
+	}
+
+	return nil
+}
+
+// pruneBinlogs deletes the binary logs at the destination that were shipped before its oldest dump was taken
+func (d *destination) pruneBinlogs(dbName string, dest backup.BinlogDestination) {
+
+	dumps, err := dest.List(dbName)
+	if err != nil {
+		logging.Error(err, logging.Fields{})
+		return
+	}
+	binlogs, err := dest.ListBinlogs(dbName)
+	if err != nil {
+		logging.Error(err, logging.Fields{})
+		return
+	}
+
+	var names []string
+	for _, dump := range dumps {
+		names = append(names, dump.Name)
+	}
+	d.delete(dbName, expiredBinlogs(binlogs, names))
+}
+
+// pendingBinlogs returns the closed binary logs newer than the newest one shipped, all of them if none were shipped yet
+func pendingBinlogs(dbName string, closed []string, shipped []backup.Dump) []string {
+
+	newest := ""
+	for _, binlog := range shipped {
+		if name := backup.BinlogOf(dbName, binlog.Name); name > newest {
+			newest = name
+		}
+	}
+
+	var pending []string
+	for _, binlog := range closed {
+		if binlog > newest {
+			pending = append(pending, binlog)
+		}
+	}
+	return pending
+}
+
+// expiredBinlogs returns the binary logs shipped before the oldest dump was taken. The binary log a dump was taken in
+// was still being written to at the time, it is shipped after the dump was taken. Without dumps, nothing expires
+func expiredBinlogs(binlogs []backup.Dump, dumps []string) []string {
+
+	sorted := sortBackups(dumps)
+	if len(sorted) == 0 {
+		return nil
+	}
+	oldest, err := parseBackupTimestamp(sorted[0])
+	if err != nil {
+		logging.Error(err, logging.Fields{})
+		return nil
+	}
+
+	var expired []string
+	for _, binlog := range binlogs {
+		if binlog.ModTime.Before(oldest) {
+			expired = append(expired, binlog.Name)
+		}
+	}
+	return expired
+}
+
+// how often binary logs are shipped, 5 minutes by default
+func binlogInterval(conf *conf.Config) time.Duration {
+
+	if conf.System.Role.Sender.BinlogInterval > 0 {
+		return time.Duration(conf.System.Role.Sender.BinlogInterval)
+	}
+	return 300
+}
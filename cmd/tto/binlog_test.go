@@ -0,0 +1,43 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"testing"
+	"time"
+)
+
+func TestPendingBinlogs(t *testing.T) {
+
+	closed := []string{"binlog.000041", "binlog.000042", "binlog.000043"}
+
+	// nothing shipped yet, all of them
+	if pending := pendingBinlogs("databaseName", closed, nil); len(pending) != 3 {
+		t.Errorf("Pending binlogs test failed; found, expected: %v, %v", pending, closed)
+	}
+
+	shipped := []backup.Dump{{Name: "databaseName_-_binlog_-_binlog.000041.gz"}, {Name: "databaseName_-_binlog_-_binlog.000042.gz"}}
+	if pending := pendingBinlogs("databaseName", closed, shipped); len(pending) != 1 || pending[0] != "binlog.000043" {
+		t.Errorf("Pending binlogs test failed; found, expected: %v, %s", pending, "binlog.000043")
+	}
+}
+
+func TestExpiredBinlogs(t *testing.T) {
+
+	binlogs := []backup.Dump{
+		{Name: "databaseName_-_binlog_-_binlog.000041", ModTime: time.Date(2019, 8, 2, 11, 0, 0, 0, time.UTC)},
+		{Name: "databaseName_-_binlog_-_binlog.000042", ModTime: time.Date(2019, 8, 2, 12, 5, 0, 0, time.UTC)},
+	}
+
+	// the binlog shipped after the oldest dump was taken is kept
+	expired := expiredBinlogs(binlogs, []string{"databaseName_-_20190803120000.sql", "databaseName_-_20190802120000.sql"})
+	if len(expired) != 1 || expired[0] != "databaseName_-_binlog_-_binlog.000041" {
+		t.Errorf("Expired binlogs test failed; found, expected: %v, %s", expired, "binlog.000041")
+	}
+
+	if expired = expiredBinlogs(binlogs, nil); len(expired) != 0 {
+		t.Errorf("Expired binlogs test failed; found, expected: %v, %s", expired, "nothing without dumps")
+	}
+}
@@ -7,9 +7,8 @@ type CircularQueue struct {
 	// size of the queue, defined by the max_backups specified in conf.json
 	size int
 
-	// circular queue is an array of structs queue{dbName string, timestamp time.Time}
-	// it has an artificial buffer limit size of 100, capping user specified max_backups
-	queue [100]struct {
+	// circular queue is a slice of structs queue{name string}, sized to max_backups
+	queue []struct {
 		name string
 	}
 
@@ -18,9 +17,14 @@ type CircularQueue struct {
 	tail int
 }
 
+// Make sizes the queue, max_backups is validated to be at least 1 when the config is loaded.
+// The size may differ from the last run, Populate then expires the oldest backups that no longer fit
 func (cq *CircularQueue) Make(size int) {
 
 	cq.size = size
+	cq.queue = make([]struct {
+		name string
+	}, size)
 	cq.head = 0
 	cq.tail = 0
 }
@@ -60,6 +64,37 @@ func (cq *CircularQueue) Enqueue(element string) string {
 	return bufOverwriteName
 }
 
+// Backups returns the backups in the queue, oldest first. The oldest is overwritten next, at the head
+func (cq *CircularQueue) Backups() []string {
+
+	var backups []string
+	for i := 0; i < cq.size; i++ {
+		if elem := cq.queue[mod(cq.head+i, cq.size)]; elem.name != "" {
+			backups = append(backups, elem.name)
+		}
+	}
+	return backups
+}
+
+// Remove forgets the backups deleted by other means, e.g. the age and size limits. The remaining backups keep their
+// order, oldest first
+func (cq *CircularQueue) Remove(backups []string) {
+
+	removed := make(map[string]bool)
+	for _, backup := range backups {
+		removed[backup] = true
+	}
+
+	var remaining []string
+	for _, backup := range cq.Backups() {
+		if !removed[backup] {
+			remaining = append(remaining, backup)
+		}
+	}
+	cq.Make(cq.size)
+	cq.Populate(remaining)
+}
+
 func (cq *CircularQueue) updateHead() {
 
 	cq.head = mod(cq.head+1, cq.size)
@@ -0,0 +1,93 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircularQueue_Populate(t *testing.T) {
+
+	// well beyond the former ceiling of 100
+	backups := hourlyBackups(time.Date(2019, 8, 2, 0, 0, 0, 0, time.UTC), 250)
+
+	buf := new(CircularQueue)
+	buf.Make(200)
+	expired := buf.Populate(backups)
+
+	if strings.Join(expired, ",") != strings.Join(backups[:50], ",") {
+		t.Errorf("Circular queue populate test failed; found, expected: %d, %d oldest backups expired", len(expired), 50)
+	}
+
+	expiredBackup := buf.Enqueue("databaseName_-_20191231000000.sql.gz")
+	if expiredBackup != backups[50] {
+		t.Errorf("Circular queue enqueue test failed; found, expected: %s, %s", expiredBackup, backups[50])
+	}
+}
+
+func TestCircularQueue_Resize(t *testing.T) {
+
+	backups := hourlyBackups(time.Date(2019, 8, 2, 0, 0, 0, 0, time.UTC), 10)
+
+	// shrinking across restarts expires the oldest backups that no longer fit
+	buf := new(CircularQueue)
+	buf.Make(4)
+	expired := buf.Populate(backups)
+	if strings.Join(expired, ",") != strings.Join(backups[:6], ",") {
+		t.Errorf("Circular queue shrink test failed; found, expected: %v, %v", expired, backups[:6])
+	}
+	if strings.Join(buf.Backups(), ",") != strings.Join(backups[6:], ",") {
+		t.Errorf("Circular queue shrink test failed; found, expected: %v, %v", buf.Backups(), backups[6:])
+	}
+
+	// growing keeps them all
+	buf = new(CircularQueue)
+	buf.Make(20)
+	if expired = buf.Populate(backups); len(expired) != 0 {
+		t.Errorf("Circular queue grow test failed; found, expected: %v, %s", expired, "nothing expired")
+	}
+	if strings.Join(buf.Backups(), ",") != strings.Join(backups, ",") {
+		t.Errorf("Circular queue grow test failed; found, expected: %v, %v", buf.Backups(), backups)
+	}
+	for _, backup := range hourlyBackups(time.Date(2019, 8, 3, 0, 0, 0, 0, time.UTC), 10) {
+		if expiredBackup := buf.Enqueue(backup); expiredBackup != "" {
+			t.Errorf("Circular queue grow test failed; found, expected: %s, %s", expiredBackup, "nothing expired")
+		}
+	}
+	if expiredBackup := buf.Enqueue("databaseName_-_20190804000000.sql.gz"); expiredBackup != backups[0] {
+		t.Errorf("Circular queue grow test failed; found, expected: %s, %s", expiredBackup, backups[0])
+	}
+
+	// a single backup
+	buf = new(CircularQueue)
+	buf.Make(1)
+	if expired = buf.Populate(backups[:2]); strings.Join(expired, ",") != backups[0] {
+		t.Errorf("Circular queue single test failed; found, expected: %v, %v", expired, backups[:1])
+	}
+}
+
+func TestCircularQueue_Remove(t *testing.T) {
+
+	backups := hourlyBackups(time.Date(2019, 8, 2, 0, 0, 0, 0, time.UTC), 4)
+
+	buf := new(CircularQueue)
+	buf.Make(4)
+	buf.Populate(backups)
+
+	// the oldest two were pruned by age, their slots are free again
+	buf.Remove(backups[:2])
+	if strings.Join(buf.Backups(), ",") != strings.Join(backups[2:], ",") {
+		t.Errorf("Circular queue remove test failed; found, expected: %v, %v", buf.Backups(), backups[2:])
+	}
+	for _, backup := range hourlyBackups(time.Date(2019, 8, 3, 0, 0, 0, 0, time.UTC), 2) {
+		if expiredBackup := buf.Enqueue(backup); expiredBackup != "" {
+			t.Errorf("Circular queue remove test failed; found, expected: %s, %s", expiredBackup, "nothing expired")
+		}
+	}
+	if expiredBackup := buf.Enqueue("databaseName_-_20190804000000.sql.gz"); expiredBackup != backups[2] {
+		t.Errorf("Circular queue remove test failed; found, expected: %s, %s", expiredBackup, backups[2])
+	}
+}
@@ -0,0 +1,72 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"io"
+	"strings"
+)
+
+// checkConfig validates the config file, then checks that the databases and destinations of the sender, or the
+// databases of the receiver, can be reached, without starting the daemon. Every check is reported to out
+func checkConfig(filename string, out io.Writer) error {
+
+	conf := new(conf.Config)
+	if err := conf.LoadConfig(filename); err != nil {
+		_, _ = fmt.Fprintln(out, "config "+filename+": failed")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			_, _ = fmt.Fprintln(out, "  "+line)
+		}
+		return errors.New("config " + filename + " is invalid")
+	}
+	_, _ = fmt.Fprintln(out, "config "+filename+": ok")
+
+	failed := 0
+	report := func(check string, err error) {
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintln(out, check+": failed: "+err.Error())
+			return
+		}
+		_, _ = fmt.Fprintln(out, check+": ok")
+	}
+
+	// a job or destination that can't be set up, e.g. an unreadable ssh key or known_hosts, is a failed check. The
+	// others are checked all the same
+	reportSetup := func(err error) {
+		if err == nil {
+			return
+		}
+		for _, line := range strings.Split(err.Error(), "\n") {
+			check, reason, _ := strings.Cut(line, ": ")
+			report(check, errors.New(reason))
+		}
+	}
+
+	switch conf.System.Type {
+	case "sender":
+		jobs, err := newJobs(conf)
+		reportSetup(err)
+		for _, j := range jobs {
+			report("db "+j.conf.DBname, j.dB.Open())
+			for _, d := range j.destinations {
+				report("destination "+d.remote.String()+" of "+j.conf.DBname, d.remote.Connect())
+			}
+		}
+	case "receiver":
+		jobs, err := newRestoreJobs(conf)
+		reportSetup(err)
+		for _, j := range jobs {
+			report("db "+j.conf.DBname, j.dB.Open())
+		}
+	}
+
+	if failed != 0 {
+		return errors.New(fmt.Sprint(failed) + " checks failed")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, config *conf.Config) string {
+
+	contents, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := t.TempDir() + "/conf.json"
+	if err = ioutil.WriteFile(filename, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestCheckConfigInvalid(t *testing.T) {
+
+	config := new(conf.Config)
+	config.MakeConfig()
+	filename := writeConfig(t, config)
+
+	var out bytes.Buffer
+	if err := checkConfig(filename, &out); err == nil {
+		t.Fatalf("Check config test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !strings.HasSuffix(lines[0], ": failed") || len(lines) < 2 || !strings.Contains(out.String(), "type must be sender or receiver") {
+		t.Errorf("Check config test failed; found, expected: %q, %s", out.String(), "every config error")
+	}
+}
+
+func TestCheckConfigSender(t *testing.T) {
+
+	config := new(conf.Config)
+	config.MakeConfig()
+	config.System.Type = "sender"
+	config.System.Role.Sender.Compression = "gzip"
+	config.System.Role.Sender.Transfer = "sftp"
+	config.System.Role.Sender.DestinationPolicy = "any"
+	config.System.Role.Sender.Cron = "0 0 * * * *"
+	// nothing listens on port 1
+	config.System.Role.Sender.DBip = net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	config.System.Role.Sender.DBport = 1
+	config.System.Role.Sender.Destinations = []conf.Destination{{Type: "local", Path: t.TempDir(), MaxBackups: 3}}
+	filename := writeConfig(t, config)
+
+	var out bytes.Buffer
+	if err := checkConfig(filename, &out); err == nil {
+		t.Fatalf("Check config test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Check config test failed; found, expected: %q, %d checks", out.String(), 3)
+	}
+	if !strings.HasSuffix(lines[0], ": ok") || !strings.HasPrefix(lines[1], "db databaseName: failed") || !strings.HasSuffix(lines[2], ": ok") {
+		t.Errorf("Check config test failed; found, expected: %q, %s", out.String(), "valid config, unreachable db, reachable destination")
+	}
+}
+
+func TestCheckConfigSSHKey(t *testing.T) {
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(dir+"/id_rsa", []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(dir+"/known_hosts", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := new(conf.Config)
+	config.MakeConfig()
+	config.System.Type = "sender"
+	config.System.SSHkey = dir + "/id_rsa"
+	config.System.KnownHosts = dir + "/known_hosts"
+	config.System.Role.Sender.Compression = "gzip"
+	config.System.Role.Sender.Transfer = "sftp"
+	config.System.Role.Sender.DestinationPolicy = "any"
+	config.System.Role.Sender.Cron = "0 0 * * * *"
+	config.System.Role.Sender.DBip = net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	config.System.Role.Sender.DBport = 1
+	config.System.Role.Sender.Destinations = []conf.Destination{{Dest: net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}, Port: 22, MaxBackups: 3}}
+	filename := writeConfig(t, config)
+
+	// the key is readable but not a key, the check reports it instead of exiting. The database is checked all the same
+	var out bytes.Buffer
+	if err := checkConfig(filename, &out); err == nil {
+		t.Fatalf("Check config ssh key test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "destination 127.0.0.1 of databaseName: failed: ssh_key") || !strings.HasPrefix(lines[2], "db databaseName: failed") {
+		t.Errorf("Check config ssh key test failed; found, expected: %q, %s", out.String(), "a failed destination check and a db check")
+	}
+}
@@ -9,13 +9,46 @@ import (
 )
 
 type Command struct {
-	Install bool
-	Remove  bool
-	Fg      bool
+	Install   bool
+	Remove    bool
+	Fg        bool
+	List      bool
+	Restore   bool
+	BackupNow bool
+	RestoreTo bool
+
+	// CheckConfig validates the config and checks the databases and destinations can be reached
+	CheckConfig bool
+
+	// Target is the dump name or timestamp to restore, or the time to restore to
+	Target string
+
+	// DBname is the database to restore to a point in time, when the receiver restores several
+	DBname string
 }
 
 func (cmd *Command) MakeCmd() error {
 
+	// restore and restore-to are the only commands taking arguments
+	if flag.Arg(0) == "restore" {
+		if len(flag.Args()) != 2 {
+			return errors.New("restore takes exactly one dump name or timestamp. See --help for more info")
+		}
+		cmd.Restore = true
+		cmd.Target = flag.Arg(1)
+		return nil
+	}
+
+	if flag.Arg(0) == "restore-to" {
+		if len(flag.Args()) != 2 && len(flag.Args()) != 3 {
+			return errors.New("restore-to takes a timestamp and optionally the database. See --help for more info")
+		}
+		cmd.RestoreTo = true
+		cmd.Target = flag.Arg(1)
+		cmd.DBname = flag.Arg(2)
+		return nil
+	}
+
 	if len(flag.Args()) > 1 {
 		return errors.New("only one command allowed, or flags should be before the command. See --help for more info")
 	}
@@ -27,6 +60,12 @@ func (cmd *Command) MakeCmd() error {
 		cmd.Remove = true
 	case "fg":
 		cmd.Fg = true
+	case "list":
+		cmd.List = true
+	case "backup-now":
+		cmd.BackupNow = true
+	case "check-config":
+		cmd.CheckConfig = true
 	default:
 		return errors.New("invalid command: " + flag.Arg(0))
 	}
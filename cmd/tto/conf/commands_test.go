@@ -15,6 +15,15 @@ var testArgs = []struct {
 	{[]string{"install"}, true},
 	{[]string{"remove"}, true},
 	{[]string{"fg"}, true},
+	{[]string{"list"}, true},
+	{[]string{"backup-now"}, true},
+	{[]string{"check-config"}, true},
+	{[]string{"restore", "20190802120000"}, true},
+	{[]string{"restore"}, false},
+	{[]string{"restore-to", "20190802120000"}, true},
+	{[]string{"restore-to", "20190802120000", "databaseName"}, true},
+	{[]string{"restore-to"}, false},
+	{[]string{"restore", "a", "b"}, false},
 	{[]string{"derp"}, false},
 	{[]string{"dum", "dum"}, false},
 	{[]string{""}, false},
@@ -42,6 +51,32 @@ func TestCommand_MakeCmd(t *testing.T) {
 			if argTest.expected != cmd.Fg {
 				t.Errorf("Input arg test failed; found, expected: %t, %t", cmd.Fg, argTest.expected)
 			}
+		case "list":
+			if argTest.expected != cmd.List {
+				t.Errorf("Input arg test failed; found, expected: %t, %t", cmd.List, argTest.expected)
+			}
+		case "backup-now":
+			if argTest.expected != cmd.BackupNow {
+				t.Errorf("Input arg test failed; found, expected: %t, %t", cmd.BackupNow, argTest.expected)
+			}
+		case "check-config":
+			if argTest.expected != cmd.CheckConfig {
+				t.Errorf("Input arg test failed; found, expected: %t, %t", cmd.CheckConfig, argTest.expected)
+			}
+		case "restore":
+			if argTest.expected != (err == nil) {
+				t.Errorf("Input arg test failed; found, expected: %#v, %t", err, argTest.expected)
+			}
+			if err == nil && cmd.Target != argTest.input[1] {
+				t.Errorf("Input arg test failed; found, expected: %s, %s", cmd.Target, argTest.input[1])
+			}
+		case "restore-to":
+			if argTest.expected != (err == nil) {
+				t.Errorf("Input arg test failed; found, expected: %#v, %t", err, argTest.expected)
+			}
+			if err == nil && (!cmd.RestoreTo || cmd.Target != argTest.input[1] || cmd.DBname != flag.Arg(2)) {
+				t.Errorf("Input arg test failed; found, expected: %+v, %v", cmd, argTest.input)
+			}
 		case "derp":
 			if err == nil {
 				t.Errorf("Input arg test failed; found, expected: %#v, %s", err, "nil err")
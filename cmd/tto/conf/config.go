@@ -5,40 +5,62 @@ package conf
 
 import (
 	"encoding/json"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/logging"
 	"net"
 	"os"
 )
 
 type Config struct {
 	System struct {
-		User       string `json:"user"`
-		Pass       string `json:"pass"`
-		SSHkey	   string `json:"ssh_key"`
-		WorkingDir string `json:"working_dir"`
-		Type       string `json:"type"`
-		Role       struct {
+		User          string         `json:"user"`
+		Pass          string         `json:"pass"`
+		SSHkey        string         `json:"ssh_key"`
+		KnownHosts    string         `json:"known_hosts"`
+		TOFU          bool           `json:"trust_on_first_use"`
+		WorkingDir    string         `json:"working_dir"`
+		HTTPListen    string         `json:"http_listen"`
+		LogFormat     string         `json:"log_format"`
+		Notifications []Notification `json:"notifications"`
+		Type          string         `json:"type"`
+		Role          struct {
 			Sender struct {
-				Dest       net.IPAddr `json:"dest"`
-				Port       uint16     `json:"port"`
-				Database   string     `json:"database"`
-				DBip       net.IPAddr `json:"db_ip"`
-				DBport     uint16     `json:"db_port"`
-				DBuser     string     `json:"db_user"`
-				DBpass     string     `json:"db_pass"`
-				DBname     string     `json:"db_name"`
-				Cron       string     `json:"cron"`
-				MaxBackups int        `json:"max_backups"`
+				Dest              net.IPAddr    `json:"dest"`
+				Port              uint16        `json:"port"`
+				HostKey           string        `json:"host_key"`
+				Transfer          string        `json:"transfer"`
+				Compression       string        `json:"compression"`
+				Recipients        []string      `json:"recipients"`
+				Database          string        `json:"database"`
+				DBip              net.IPAddr    `json:"db_ip"`
+				DBport            uint16        `json:"db_port"`
+				DBuser            string        `json:"db_user"`
+				DBpass            string        `json:"db_pass"`
+				DBname            string        `json:"db_name"`
+				Cron              string        `json:"cron"`
+				MaxBackups        int           `json:"max_backups"`
+				Retention         Retention     `json:"retention"`
+				MaxAgeDays        int           `json:"max_age_days"`
+				MaxSizeGB         int           `json:"max_size_gb"`
+				Jobs              []Job         `json:"jobs"`
+				Destinations      []Destination `json:"destinations"`
+				DestinationPolicy string        `json:"destination_policy"`
+				Binlogs           bool          `json:"binlogs"`
+				BinlogInterval    int           `json:"binlog_interval"`
 			}
 			Receiver struct {
-				Database   string     `json:"database"`
-				DBip       net.IPAddr `json:"db_ip"`
-				DBport     uint16     `json:"db_port"`
-				DBuser     string     `json:"db_user"`
-				DBpass     string     `json:"db_pass"`
-				DBname     string     `json:"db_name"`
-				ExecBefore []string   `json:"exec_before"`
-				ExecAfter  []string   `json:"exec_after"`
+				Database             string        `json:"database"`
+				DBip                 net.IPAddr    `json:"db_ip"`
+				DBport               uint16        `json:"db_port"`
+				DBuser               string        `json:"db_user"`
+				DBpass               string        `json:"db_pass"`
+				DBname               string        `json:"db_name"`
+				IdentityFile         string        `json:"identity_file"`
+				RestoreMode          string        `json:"restore_mode"`
+				RestoreEngine        string        `json:"restore_engine"`
+				AllowMissingChecksum bool          `json:"allow_missing_checksum"`
+				ExecBefore           []string      `json:"exec_before"`
+				ExecAfter            []string      `json:"exec_after"`
+				Jobs                 []ReceiverJob `json:"jobs"`
 			}
 		}
 	}
@@ -49,39 +71,56 @@ func (conf *Config) MakeConfig() {
 	conf.System.User = `username`
 	conf.System.Pass = `password`
 	conf.System.SSHkey = `/home/user/.ssh/id_rsa`
+	conf.System.KnownHosts = `/home/user/.ssh/known_hosts`
+	conf.System.TOFU = false
 	conf.System.WorkingDir = `/opt/tto/`
+	conf.System.HTTPListen = ``
+	conf.System.LogFormat = `text`
+	conf.System.Notifications = []Notification{}
 	conf.System.Type = `sender|receiver`
-	conf.System.Role.Sender.Dest = net.IPAddr{net.IPv4(6, 6, 6, 6), ""}
+	conf.System.Role.Sender.Dest = net.IPAddr{IP: net.IPv4(6, 6, 6, 6), Zone: ""}
 	conf.System.Role.Sender.Port = uint16(22)
+	conf.System.Role.Sender.HostKey = ``
+	conf.System.Role.Sender.Transfer = `sftp|scp`
+	conf.System.Role.Sender.Compression = `none|gzip|zstd`
+	conf.System.Role.Sender.Recipients = []string{}
 	conf.System.Role.Sender.Database = `mysql`
-	conf.System.Role.Sender.DBip = net.IPAddr{net.IPv4(7, 7, 7, 7), ""}
+	conf.System.Role.Sender.DBip = net.IPAddr{IP: net.IPv4(7, 7, 7, 7), Zone: ""}
 	conf.System.Role.Sender.DBport = uint16(3306)
 	conf.System.Role.Sender.DBuser = `username`
 	conf.System.Role.Sender.DBpass = `password`
 	conf.System.Role.Sender.DBname = `databaseName`
 	conf.System.Role.Sender.Cron = `a cron statement`
 	conf.System.Role.Sender.MaxBackups = int(5)
+	conf.System.Role.Sender.Jobs = []Job{}
+	conf.System.Role.Sender.Destinations = []Destination{}
+	conf.System.Role.Sender.DestinationPolicy = "any|all"
+	conf.System.Role.Sender.Binlogs = false
+	conf.System.Role.Sender.BinlogInterval = int(300)
 	conf.System.Role.Receiver.Database = `mysql`
-	conf.System.Role.Receiver.DBip = net.IPAddr{net.IPv4(8, 8, 8, 8), ""}
+	conf.System.Role.Receiver.DBip = net.IPAddr{IP: net.IPv4(8, 8, 8, 8), Zone: ""}
 	conf.System.Role.Receiver.DBport = uint16(3306)
 	conf.System.Role.Receiver.DBuser = `username`
 	conf.System.Role.Receiver.DBpass = `password`
 	conf.System.Role.Receiver.DBname = `databaseName`
+	conf.System.Role.Receiver.IdentityFile = ``
+	conf.System.Role.Receiver.RestoreMode = `direct|atomic`
+	conf.System.Role.Receiver.RestoreEngine = `driver|cli`
+	conf.System.Role.Receiver.AllowMissingChecksum = false
 	conf.System.Role.Receiver.ExecBefore = []string{"echo", "i run before restoring the database"}
 	conf.System.Role.Receiver.ExecAfter = []string{"echo", "i run after restoring the database"}
+	conf.System.Role.Receiver.Jobs = []ReceiverJob{}
 }
 
 func (conf *Config) LoadConfig(filename string) error {
 
-	// TODO: config file input validation. Depends if the app is a sender or receiver
-
 	fd, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if err := fd.Close(); err != nil {
-			glog.Exit(err)
+			logging.Error(err, logging.Fields{Event: logging.Config})
 		}
 	}()
 
@@ -90,5 +129,5 @@ func (conf *Config) LoadConfig(filename string) error {
 		return err
 	}
 
-	return nil
+	return conf.Validate()
 }
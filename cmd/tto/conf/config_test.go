@@ -20,6 +20,12 @@ func TestConfig_MakeConfig(t *testing.T) {
 	if !(conf.System.Pass == "password") {
 		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.User, "password")
 	}
+	if !(conf.System.KnownHosts == "/home/user/.ssh/known_hosts") {
+		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.KnownHosts, "/home/user/.ssh/known_hosts")
+	}
+	if conf.System.TOFU {
+		t.Errorf("Make config test failed; found, expected: %t, %t", conf.System.TOFU, false)
+	}
 	if !(conf.System.WorkingDir == "/opt/tto/") {
 		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.User, "/opt/tto/")
 	}
@@ -34,6 +40,12 @@ func TestConfig_MakeConfig(t *testing.T) {
 	if !(conf.System.Role.Sender.Port == 22) {
 		t.Errorf("Make config test failed; found, expected: %d, %d", conf.System.Role.Sender.Port, 22)
 	}
+	if !(conf.System.Role.Sender.Transfer == "sftp|scp") {
+		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.Role.Sender.Transfer, "sftp|scp")
+	}
+	if !(conf.System.Role.Sender.Compression == "none|gzip|zstd") {
+		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.Role.Sender.Compression, "none|gzip|zstd")
+	}
 	if !(conf.System.Role.Sender.Database == "mysql") {
 		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.Role.Sender.Database, "mysql")
 	}
@@ -58,6 +70,9 @@ func TestConfig_MakeConfig(t *testing.T) {
 	if !(conf.System.Role.Sender.MaxBackups == 5) {
 		t.Errorf("Make config test failed; found, expected: %d, %d", conf.System.Role.Sender.MaxBackups, 5)
 	}
+	if !(conf.System.Role.Sender.DestinationPolicy == "any|all") {
+		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.Role.Sender.DestinationPolicy, "any|all")
+	}
 
 	// receiver config tests
 	if !(conf.System.Role.Receiver.Database == "mysql") {
@@ -78,6 +93,12 @@ func TestConfig_MakeConfig(t *testing.T) {
 	if !(conf.System.Role.Receiver.DBname == "databaseName") {
 		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.Role.Receiver.DBname, "databaseName")
 	}
+	if !(conf.System.Role.Receiver.RestoreMode == "direct|atomic") {
+		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.Role.Receiver.RestoreMode, "direct|atomic")
+	}
+	if !(conf.System.Role.Receiver.RestoreEngine == "driver|cli") {
+		t.Errorf("Make config test failed; found, expected: %s, %s", conf.System.Role.Receiver.RestoreEngine, "driver|cli")
+	}
 	if len(conf.System.Role.Receiver.ExecBefore) == 0 {
 		t.Errorf("Make config test failed; found, expected: %d, %d", len(conf.System.Role.Receiver.ExecBefore), 0)
 	}
@@ -0,0 +1,280 @@
+// Craig Tomkow
+// October 18, 2026
+
+package conf
+
+import (
+	"errors"
+	"net"
+	"strconv"
+)
+
+// Job is a backup job of the sender, one per database
+type Job struct {
+	Database   string     `json:"database"`
+	DBip       net.IPAddr `json:"db_ip"`
+	DBport     uint16     `json:"db_port"`
+	DBuser     string     `json:"db_user"`
+	DBpass     string     `json:"db_pass"`
+	DBname     string     `json:"db_name"`
+	Cron       string     `json:"cron"`
+	MaxBackups int        `json:"max_backups"`
+	Retention  Retention  `json:"retention"`
+	MaxAgeDays int        `json:"max_age_days"`
+	MaxSizeGB  int        `json:"max_size_gb"`
+	Dest       net.IPAddr `json:"dest"`
+	Port       uint16     `json:"port"`
+	HostKey    string     `json:"host_key"`
+
+	// ship the binary logs of the database to the receivers, for point-in-time recovery. mysql only.
+	// Left out, the job inherits binlogs of the sender, false opts out
+	Binlogs *bool `json:"binlogs"`
+
+	// Destinations take precedence over Dest, Port and HostKey
+	Destinations []Destination `json:"destinations"`
+}
+
+// ShipsBinlogs reports whether the binary logs of the job's database are shipped
+func (job Job) ShipsBinlogs() bool {
+	return job.Binlogs != nil && *job.Binlogs
+}
+
+// Destination is where the dumps of a job are streamed to, with its own retention.
+// Type "ssh" (default) is a tto receiver at Dest, type "s3" an S3-compatible bucket at Endpoint and
+// type "local" a directory at Path on the sender itself
+type Destination struct {
+	Type       string     `json:"type"`
+	Dest       net.IPAddr `json:"dest"`
+	Port       uint16     `json:"port"`
+	HostKey    string     `json:"host_key"`
+	MaxBackups int        `json:"max_backups"`
+	Retention  Retention  `json:"retention"`
+	MaxAgeDays int        `json:"max_age_days"`
+	MaxSizeGB  int        `json:"max_size_gb"`
+	Endpoint   string     `json:"endpoint"`
+	Region     string     `json:"region"`
+	Bucket     string     `json:"bucket"`
+	Prefix     string     `json:"prefix"`
+	AccessKey  string     `json:"access_key"`
+	SecretKey  string     `json:"secret_key"`
+	Path       string     `json:"path"`
+}
+
+// Retention is a grandfather-father-son retention policy: the newest dump of each of the last Hourly hours, Daily days,
+// Weekly weeks and Monthly months is kept. When set, it replaces the max_backups ring buffer
+type Retention struct {
+	Hourly  int `json:"hourly"`
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+}
+
+// IsSet reports whether any of the retention periods are configured
+func (retention Retention) IsSet() bool {
+	return retention.Hourly > 0 || retention.Daily > 0 || retention.Weekly > 0 || retention.Monthly > 0
+}
+
+// validateRetention ensures every destination of the sender keeps at least one dump and has sane limits
+func (conf *Config) validateRetention() error {
+
+	var errs []error
+	for _, job := range conf.SenderJobs() {
+		for _, destination := range job.Destinations {
+			retention := destination.Retention
+			if retention.Hourly < 0 || retention.Daily < 0 || retention.Weekly < 0 || retention.Monthly < 0 {
+				errs = append(errs, errors.New("retention of "+job.DBname+" can't be negative"))
+			}
+			if destination.MaxAgeDays < 0 || destination.MaxSizeGB < 0 {
+				errs = append(errs, errors.New("max_age_days and max_size_gb of "+job.DBname+" can't be negative"))
+			}
+			if !retention.IsSet() && destination.MaxBackups < 1 {
+				errs = append(errs, errors.New("max_backups of "+job.DBname+" must be at least 1, found "+strconv.Itoa(destination.MaxBackups)))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ReceiverJob is a restore job of the receiver, one per database
+type ReceiverJob struct {
+	Database   string     `json:"database"`
+	DBip       net.IPAddr `json:"db_ip"`
+	DBport     uint16     `json:"db_port"`
+	DBuser     string     `json:"db_user"`
+	DBpass     string     `json:"db_pass"`
+	DBname     string     `json:"db_name"`
+	ExecBefore []string   `json:"exec_before"`
+	ExecAfter  []string   `json:"exec_after"`
+}
+
+// SenderJobs returns the backup jobs of the sender. Without any jobs configured, the sender itself is the only job.
+// Settings a job leaves empty are inherited from the sender, e.g. to back up several databases of the same server
+func (conf *Config) SenderJobs() []Job {
+
+	sender := conf.System.Role.Sender
+	defaults := Job{
+		Database:   sender.Database,
+		DBip:       sender.DBip,
+		DBport:     sender.DBport,
+		DBuser:     sender.DBuser,
+		DBpass:     sender.DBpass,
+		DBname:     sender.DBname,
+		Cron:       sender.Cron,
+		MaxBackups: sender.MaxBackups,
+		Retention:  sender.Retention,
+		MaxAgeDays: sender.MaxAgeDays,
+		MaxSizeGB:  sender.MaxSizeGB,
+		Dest:       sender.Dest,
+		Port:       sender.Port,
+		HostKey:    sender.HostKey,
+		Binlogs:    &sender.Binlogs,
+
+		Destinations: sender.Destinations,
+	}
+
+	if len(sender.Jobs) == 0 {
+		return []Job{withDestinations(defaults, nil)}
+	}
+
+	var jobs []Job
+	for _, job := range sender.Jobs {
+		if job.Database == "" {
+			job.Database = defaults.Database
+		}
+		if job.DBip.IP == nil {
+			job.DBip = defaults.DBip
+		}
+		if job.DBport == 0 {
+			job.DBport = defaults.DBport
+		}
+		if job.DBuser == "" {
+			job.DBuser = defaults.DBuser
+		}
+		if job.DBpass == "" {
+			job.DBpass = defaults.DBpass
+		}
+		if job.DBname == "" {
+			job.DBname = defaults.DBname
+		}
+		if job.Cron == "" {
+			job.Cron = defaults.Cron
+		}
+		// a job with its own max_backups sticks to the ring buffer
+		if !job.Retention.IsSet() && job.MaxBackups == 0 {
+			job.Retention = defaults.Retention
+		}
+		if job.MaxBackups == 0 {
+			job.MaxBackups = defaults.MaxBackups
+		}
+		if job.MaxAgeDays == 0 {
+			job.MaxAgeDays = defaults.MaxAgeDays
+		}
+		if job.MaxSizeGB == 0 {
+			job.MaxSizeGB = defaults.MaxSizeGB
+		}
+		if job.Dest.IP == nil {
+			job.Dest = defaults.Dest
+		}
+		if job.Port == 0 {
+			job.Port = defaults.Port
+		}
+		if job.HostKey == "" {
+			job.HostKey = defaults.HostKey
+		}
+		if job.Binlogs == nil {
+			job.Binlogs = defaults.Binlogs
+		}
+		jobs = append(jobs, withDestinations(job, defaults.Destinations))
+	}
+
+	return jobs
+}
+
+// withDestinations fills in the destinations of the job. Without any, the job inherits the given ones, or else streams
+// to its single Dest. Port, MaxBackups, Retention, MaxAgeDays and MaxSizeGB a destination leaves empty are taken from the job
+func withDestinations(job Job, inherited []Destination) Job {
+
+	destinations := job.Destinations
+	if len(destinations) == 0 {
+		destinations = inherited
+	}
+	if len(destinations) == 0 {
+		destinations = []Destination{{Dest: job.Dest, Port: job.Port, HostKey: job.HostKey}}
+	}
+
+	job.Destinations = nil
+	for _, destination := range destinations {
+		if destination.Port == 0 {
+			destination.Port = job.Port
+		}
+		// a destination with its own max_backups sticks to the ring buffer
+		if !destination.Retention.IsSet() && destination.MaxBackups == 0 {
+			destination.Retention = job.Retention
+		}
+		if destination.MaxBackups == 0 {
+			destination.MaxBackups = job.MaxBackups
+		}
+		if destination.MaxAgeDays == 0 {
+			destination.MaxAgeDays = job.MaxAgeDays
+		}
+		if destination.MaxSizeGB == 0 {
+			destination.MaxSizeGB = job.MaxSizeGB
+		}
+		job.Destinations = append(job.Destinations, destination)
+	}
+
+	return job
+}
+
+// ReceiverJobs returns the restore jobs of the receiver. Without any jobs configured, the receiver itself is the only job.
+// Settings a job leaves empty are inherited from the receiver
+func (conf *Config) ReceiverJobs() []ReceiverJob {
+
+	receiver := conf.System.Role.Receiver
+	defaults := ReceiverJob{
+		Database:   receiver.Database,
+		DBip:       receiver.DBip,
+		DBport:     receiver.DBport,
+		DBuser:     receiver.DBuser,
+		DBpass:     receiver.DBpass,
+		DBname:     receiver.DBname,
+		ExecBefore: receiver.ExecBefore,
+		ExecAfter:  receiver.ExecAfter,
+	}
+
+	if len(receiver.Jobs) == 0 {
+		return []ReceiverJob{defaults}
+	}
+
+	var jobs []ReceiverJob
+	for _, job := range receiver.Jobs {
+		if job.Database == "" {
+			job.Database = defaults.Database
+		}
+		if job.DBip.IP == nil {
+			job.DBip = defaults.DBip
+		}
+		if job.DBport == 0 {
+			job.DBport = defaults.DBport
+		}
+		if job.DBuser == "" {
+			job.DBuser = defaults.DBuser
+		}
+		if job.DBpass == "" {
+			job.DBpass = defaults.DBpass
+		}
+		if job.DBname == "" {
+			job.DBname = defaults.DBname
+		}
+		if len(job.ExecBefore) == 0 {
+			job.ExecBefore = defaults.ExecBefore
+		}
+		if len(job.ExecAfter) == 0 {
+			job.ExecAfter = defaults.ExecAfter
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs
+}
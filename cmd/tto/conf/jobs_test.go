@@ -0,0 +1,185 @@
+// Craig Tomkow
+// October 18, 2026
+
+package conf
+
+import (
+	"net"
+	"testing"
+)
+
+func TestConfig_SenderJobs(t *testing.T) {
+
+	conf := new(Config)
+	conf.MakeConfig()
+
+	// without jobs, the sender is the only job
+	jobs := conf.SenderJobs()
+	if len(jobs) != 1 {
+		t.Fatalf("Sender jobs test failed; found, expected: %d, %d", len(jobs), 1)
+	}
+	if !(jobs[0].DBname == "databaseName" && jobs[0].Cron == "a cron statement" && jobs[0].MaxBackups == 5) {
+		t.Errorf("Sender jobs test failed; found, expected: %+v, %s", jobs[0], "the sender's settings")
+	}
+
+	// jobs inherit what they leave empty
+	conf.System.Role.Sender.Jobs = []Job{
+		{DBname: "first"},
+		{DBname: "second", Cron: "@hourly", MaxBackups: 24, Dest: net.IPAddr{IP: net.IPv4(9, 9, 9, 9)}},
+	}
+	jobs = conf.SenderJobs()
+	if len(jobs) != 2 {
+		t.Fatalf("Sender jobs test failed; found, expected: %d, %d", len(jobs), 2)
+	}
+	if !(jobs[0].DBname == "first" && jobs[0].Cron == "a cron statement" && jobs[0].MaxBackups == 5 && jobs[0].Dest.IP.Equal(net.IP{6, 6, 6, 6})) {
+		t.Errorf("Sender jobs test failed; found, expected: %+v, %s", jobs[0], "inherited settings")
+	}
+	if !(jobs[1].DBname == "second" && jobs[1].Cron == "@hourly" && jobs[1].MaxBackups == 24 && jobs[1].Dest.IP.Equal(net.IP{9, 9, 9, 9})) {
+		t.Errorf("Sender jobs test failed; found, expected: %+v, %s", jobs[1], "own settings")
+	}
+	if !(jobs[1].Database == "mysql" && jobs[1].DBport == 3306 && jobs[1].Port == 22) {
+		t.Errorf("Sender jobs test failed; found, expected: %+v, %s", jobs[1], "inherited settings")
+	}
+
+	// binlogs enabled on the sender are enabled for every job that doesn't opt out
+	enabled, disabled := true, false
+	conf.System.Role.Sender.Binlogs = true
+	conf.System.Role.Sender.Jobs = []Job{{DBname: "first"}, {DBname: "second", Binlogs: &enabled}, {DBname: "third", Binlogs: &disabled}}
+	jobs = conf.SenderJobs()
+	for _, job := range jobs[:2] {
+		if !job.ShipsBinlogs() {
+			t.Errorf("Sender jobs test failed; found, expected: %+v, %s", job, "inherited binlogs")
+		}
+	}
+	if jobs[2].ShipsBinlogs() {
+		t.Errorf("Sender jobs test failed; found, expected: %+v, %s", jobs[2], "opted out of binlogs")
+	}
+
+	// and the other way around, a job can ship binlogs on its own
+	conf.System.Role.Sender.Binlogs = false
+	jobs = conf.SenderJobs()
+	if jobs[0].ShipsBinlogs() || !jobs[1].ShipsBinlogs() || jobs[2].ShipsBinlogs() {
+		t.Errorf("Sender jobs test failed; found, expected: %v %v %v, %s", jobs[0].ShipsBinlogs(), jobs[1].ShipsBinlogs(), jobs[2].ShipsBinlogs(), "binlogs of the second job only")
+	}
+}
+
+func TestConfig_SenderJobsDestinations(t *testing.T) {
+
+	conf := new(Config)
+	conf.MakeConfig()
+
+	// without destinations, a job streams to its single dest
+	jobs := conf.SenderJobs()
+	if len(jobs[0].Destinations) != 1 {
+		t.Fatalf("Sender job destinations test failed; found, expected: %d, %d", len(jobs[0].Destinations), 1)
+	}
+	if !(jobs[0].Destinations[0].Dest.IP.Equal(net.IP{6, 6, 6, 6}) && jobs[0].Destinations[0].Port == 22 && jobs[0].Destinations[0].MaxBackups == 5) {
+		t.Errorf("Sender job destinations test failed; found, expected: %+v, %s", jobs[0].Destinations[0], "the sender's dest")
+	}
+
+	// the sender's destinations are inherited, a job's own take precedence
+	conf.System.Role.Sender.Destinations = []Destination{
+		{Dest: net.IPAddr{IP: net.IPv4(1, 1, 1, 1)}},
+		{Dest: net.IPAddr{IP: net.IPv4(2, 2, 2, 2)}, Port: 2222, MaxBackups: 30},
+	}
+	conf.System.Role.Sender.Jobs = []Job{
+		{DBname: "first"},
+		{DBname: "second", MaxBackups: 7, Destinations: []Destination{{Dest: net.IPAddr{IP: net.IPv4(3, 3, 3, 3)}}}},
+	}
+	jobs = conf.SenderJobs()
+
+	if len(jobs[0].Destinations) != 2 {
+		t.Fatalf("Sender job destinations test failed; found, expected: %d, %d", len(jobs[0].Destinations), 2)
+	}
+	if !(jobs[0].Destinations[0].Port == 22 && jobs[0].Destinations[0].MaxBackups == 5) {
+		t.Errorf("Sender job destinations test failed; found, expected: %+v, %s", jobs[0].Destinations[0], "inherited port and max backups")
+	}
+	if !(jobs[0].Destinations[1].Port == 2222 && jobs[0].Destinations[1].MaxBackups == 30) {
+		t.Errorf("Sender job destinations test failed; found, expected: %+v, %s", jobs[0].Destinations[1], "own port and max backups")
+	}
+	if len(jobs[1].Destinations) != 1 {
+		t.Fatalf("Sender job destinations test failed; found, expected: %d, %d", len(jobs[1].Destinations), 1)
+	}
+	if !(jobs[1].Destinations[0].Dest.IP.Equal(net.IP{3, 3, 3, 3}) && jobs[1].Destinations[0].MaxBackups == 7) {
+		t.Errorf("Sender job destinations test failed; found, expected: %+v, %s", jobs[1].Destinations[0], "the job's destination")
+	}
+}
+
+func TestConfig_ReceiverJobs(t *testing.T) {
+
+	conf := new(Config)
+	conf.MakeConfig()
+
+	// without jobs, the receiver is the only job
+	jobs := conf.ReceiverJobs()
+	if len(jobs) != 1 {
+		t.Fatalf("Receiver jobs test failed; found, expected: %d, %d", len(jobs), 1)
+	}
+	if !(jobs[0].DBname == "databaseName" && len(jobs[0].ExecBefore) != 0) {
+		t.Errorf("Receiver jobs test failed; found, expected: %+v, %s", jobs[0], "the receiver's settings")
+	}
+
+	// jobs inherit what they leave empty
+	conf.System.Role.Receiver.Jobs = []ReceiverJob{
+		{DBname: "first", ExecAfter: []string{"true"}},
+	}
+	jobs = conf.ReceiverJobs()
+	if len(jobs) != 1 {
+		t.Fatalf("Receiver jobs test failed; found, expected: %d, %d", len(jobs), 1)
+	}
+	if !(jobs[0].DBname == "first" && jobs[0].DBip.IP.Equal(net.IP{8, 8, 8, 8}) && len(jobs[0].ExecBefore) != 0) {
+		t.Errorf("Receiver jobs test failed; found, expected: %+v, %s", jobs[0], "inherited settings")
+	}
+	if !(len(jobs[0].ExecAfter) == 1 && jobs[0].ExecAfter[0] == "true") {
+		t.Errorf("Receiver jobs test failed; found, expected: %v, %v", jobs[0].ExecAfter, []string{"true"})
+	}
+}
+
+func TestConfig_SenderJobsRetention(t *testing.T) {
+
+	conf := new(Config)
+	conf.MakeConfig()
+	conf.System.Role.Sender.Retention = Retention{Hourly: 24, Daily: 14, Weekly: 8, Monthly: 12}
+	conf.System.Role.Sender.Jobs = []Job{
+		{DBname: "first"},
+		{DBname: "second", MaxBackups: 3},
+		{DBname: "third", Destinations: []Destination{{Dest: net.IPAddr{IP: net.IPv4(1, 1, 1, 1)}, MaxBackups: 10}, {Dest: net.IPAddr{IP: net.IPv4(2, 2, 2, 2)}}}},
+	}
+	jobs := conf.SenderJobs()
+
+	// the sender's retention is inherited, unless a job or destination has its own max_backups
+	if !(jobs[0].Retention.IsSet() && jobs[0].Destinations[0].Retention.Daily == 14) {
+		t.Errorf("Sender job retention test failed; found, expected: %+v, %s", jobs[0].Destinations[0], "inherited retention")
+	}
+	if jobs[1].Retention.IsSet() || jobs[1].Destinations[0].Retention.IsSet() {
+		t.Errorf("Sender job retention test failed; found, expected: %+v, %s", jobs[1].Destinations[0], "ring buffer")
+	}
+	if jobs[2].Destinations[0].Retention.IsSet() || !jobs[2].Destinations[1].Retention.IsSet() {
+		t.Errorf("Sender job retention test failed; found, expected: %+v, %s", jobs[2].Destinations, "ring buffer, then retention")
+	}
+}
+
+func TestConfig_validateRetention(t *testing.T) {
+
+	conf := new(Config)
+	conf.MakeConfig()
+	if err := conf.validateRetention(); err != nil {
+		t.Errorf("Validate retention test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	conf.System.Role.Sender.MaxBackups = 0
+	if err := conf.validateRetention(); err == nil {
+		t.Errorf("Validate retention test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+
+	// retention takes the place of max_backups
+	conf.System.Role.Sender.Retention = Retention{Daily: 7}
+	if err := conf.validateRetention(); err != nil {
+		t.Errorf("Validate retention test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	conf.System.Role.Sender.Retention = Retention{Daily: 7, Weekly: -1}
+	if err := conf.validateRetention(); err == nil {
+		t.Errorf("Validate retention test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}
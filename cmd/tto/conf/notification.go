@@ -0,0 +1,21 @@
+// Craig Tomkow
+// October 18, 2026
+
+package conf
+
+// Notification is where the outcome of dumps, transfers, deletes and restores is sent.
+// Type "webhook" POSTs the event as JSON to URL, type "smtp" emails it through Server ("host:port") and type "command"
+// runs Command with the event on stdin. Events limits it to some of "dump", "transfer", "delete" and "restore".
+// On is "failure" (default), "success" or "always"
+type Notification struct {
+	Type     string   `json:"type"`
+	Events   []string `json:"events"`
+	On       string   `json:"on"`
+	URL      string   `json:"url"`
+	Server   string   `json:"server"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Command  []string `json:"command"`
+}
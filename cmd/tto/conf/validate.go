@@ -0,0 +1,240 @@
+// Craig Tomkow
+// October 18, 2026
+
+package conf
+
+import (
+	"errors"
+	"filippo.io/age"
+	"github.com/ctomkow/tto/cmd/tto/inet"
+	"github.com/robfig/cron"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Validate checks the config of the sender or receiver, whichever it is, before anything is started.
+// All errors found are returned at once, one per line
+func (conf *Config) Validate() error {
+
+	var errs []error
+
+	if conf.System.WorkingDir == "" || !strings.HasSuffix(conf.System.WorkingDir, "/") {
+		errs = append(errs, errors.New("working_dir must be a directory ending in /, found: "+conf.System.WorkingDir))
+	}
+	if conf.System.LogFormat != "" && conf.System.LogFormat != "text" && conf.System.LogFormat != "json" {
+		errs = append(errs, errors.New("log_format must be text or json, found: "+conf.System.LogFormat))
+	}
+	if conf.System.HTTPListen != "" {
+		if _, _, err := net.SplitHostPort(conf.System.HTTPListen); err != nil {
+			errs = append(errs, errors.New("http_listen must be host:port, e.g. :9469: "+err.Error()))
+		}
+	}
+	for i, notification := range conf.System.Notifications {
+		if err := notification.validate(i); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch conf.System.Type {
+	case "sender":
+		errs = append(errs, conf.validateSender()...)
+	case "receiver":
+		errs = append(errs, conf.validateReceiver()...)
+	default:
+		errs = append(errs, errors.New("type must be sender or receiver, found: "+conf.System.Type))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (conf *Config) validateSender() []error {
+
+	var errs []error
+	sender := conf.System.Role.Sender
+
+	if sender.Transfer != "" && sender.Transfer != "sftp" && sender.Transfer != "scp" {
+		errs = append(errs, errors.New("transfer must be sftp or scp, found: "+sender.Transfer))
+	}
+	if sender.Compression != "" && sender.Compression != "none" && sender.Compression != "gzip" && sender.Compression != "zstd" {
+		errs = append(errs, errors.New("compression must be none, gzip or zstd, found: "+sender.Compression))
+	}
+	for _, recipient := range sender.Recipients {
+		if _, err := age.ParseX25519Recipient(recipient); err != nil {
+			errs = append(errs, errors.New("recipient "+recipient+": "+err.Error()))
+		}
+	}
+	if sender.DestinationPolicy != "" && sender.DestinationPolicy != "any" && sender.DestinationPolicy != "all" {
+		errs = append(errs, errors.New("destination_policy must be any or all, found: "+sender.DestinationPolicy))
+	}
+	if sender.BinlogInterval < 0 {
+		errs = append(errs, errors.New("binlog_interval can't be negative"))
+	}
+	if err := conf.validateRetention(); err != nil {
+		errs = append(errs, err)
+	}
+
+	sshKeyNeeded, knownHostsNeeded := false, false
+	for _, job := range conf.SenderJobs() {
+		prefix := "job " + job.DBname + ": "
+
+		if job.DBname == "" {
+			errs = append(errs, errors.New("db_name of a job is empty"))
+		}
+		if job.Database != "mysql" && job.Database != "postgres" {
+			errs = append(errs, errors.New(prefix+"database must be mysql or postgres, found: "+job.Database))
+		}
+		if job.ShipsBinlogs() && job.Database != "mysql" {
+			errs = append(errs, errors.New(prefix+"binlogs need a mysql database"))
+		}
+		if _, err := cron.Parse(job.Cron); err != nil {
+			errs = append(errs, errors.New(prefix+"cron "+strconv.Quote(job.Cron)+": "+err.Error()))
+		}
+
+		receivers := 0
+		for _, destination := range job.Destinations {
+			switch destination.Type {
+			case "", "ssh":
+				sshKeyNeeded = true
+				receivers++
+				// a pinned host key takes precedence, with tofu known_hosts is created on first connect
+				if destination.HostKey == "" && !conf.System.TOFU {
+					knownHostsNeeded = true
+				}
+				if destination.Dest.IP == nil {
+					errs = append(errs, errors.New(prefix+"ssh destination has no dest"))
+				}
+				if destination.Port == 0 {
+					errs = append(errs, errors.New(prefix+"ssh destination "+destination.Dest.String()+" has no port"))
+				}
+			case "s3":
+				if destination.Bucket == "" || destination.Endpoint == "" {
+					errs = append(errs, errors.New(prefix+"s3 destination needs an endpoint and a bucket"))
+				}
+			case "local":
+				if destination.Path == "" {
+					errs = append(errs, errors.New(prefix+"local destination has no path"))
+				}
+			default:
+				errs = append(errs, errors.New(prefix+"unknown destination type: "+destination.Type))
+			}
+		}
+		// binary logs are only replayed by a receiver, buckets and local copies don't keep them
+		if job.ShipsBinlogs() && receivers == 0 {
+			errs = append(errs, errors.New(prefix+"binlogs need an ssh destination, they are shipped to receivers only"))
+		}
+	}
+
+	if sshKeyNeeded {
+		if err := readable("ssh_key", conf.System.SSHkey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if knownHostsNeeded {
+		knownHosts := conf.System.KnownHosts
+		if knownHosts == "" {
+			var err error
+			if knownHosts, err = inet.DefaultKnownHosts(); err != nil {
+				errs = append(errs, errors.New("known_hosts: "+err.Error()))
+			}
+		}
+		if knownHosts != "" {
+			if err := readable("known_hosts", knownHosts); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (conf *Config) validateReceiver() []error {
+
+	var errs []error
+	receiver := conf.System.Role.Receiver
+
+	if receiver.RestoreMode != "" && receiver.RestoreMode != "direct" && receiver.RestoreMode != "atomic" {
+		errs = append(errs, errors.New("restore_mode must be direct or atomic, found: "+receiver.RestoreMode))
+	}
+	if receiver.RestoreEngine != "" && receiver.RestoreEngine != "driver" && receiver.RestoreEngine != "cli" {
+		errs = append(errs, errors.New("restore_engine must be driver or cli, found: "+receiver.RestoreEngine))
+	}
+	// an atomic restore swaps in the database the driver restored into, the cli can't do that
+	if receiver.RestoreMode == "atomic" && receiver.RestoreEngine == "cli" {
+		errs = append(errs, errors.New("restore_mode atomic needs restore_engine driver, found: cli"))
+	}
+	if receiver.IdentityFile != "" {
+		if err := readable("identity_file", receiver.IdentityFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, job := range conf.ReceiverJobs() {
+		prefix := "job " + job.DBname + ": "
+
+		if job.DBname == "" {
+			errs = append(errs, errors.New("db_name of a job is empty"))
+		} else if seen[job.DBname] {
+			errs = append(errs, errors.New(prefix+"restored more than once"))
+		}
+		seen[job.DBname] = true
+
+		if job.Database != "mysql" && job.Database != "postgres" {
+			errs = append(errs, errors.New(prefix+"database must be mysql or postgres, found: "+job.Database))
+		}
+		if len(job.ExecBefore) == 0 || len(job.ExecAfter) == 0 {
+			errs = append(errs, errors.New(prefix+"exec_before and exec_after need a command, e.g. [\"true\"]"))
+		}
+	}
+
+	return errs
+}
+
+func (notification Notification) validate(i int) error {
+
+	prefix := "notification " + strconv.Itoa(i+1) + ": "
+
+	var errs []error
+	switch notification.Type {
+	case "webhook":
+		if notification.URL == "" {
+			errs = append(errs, errors.New(prefix+"webhook has no url"))
+		}
+	case "smtp":
+		if _, _, err := net.SplitHostPort(notification.Server); err != nil {
+			errs = append(errs, errors.New(prefix+"smtp server must be host:port: "+err.Error()))
+		}
+		if notification.From == "" || len(notification.To) == 0 {
+			errs = append(errs, errors.New(prefix+"smtp needs from and to"))
+		}
+	case "command":
+		if len(notification.Command) == 0 {
+			errs = append(errs, errors.New(prefix+"command notification has no command"))
+		}
+	default:
+		errs = append(errs, errors.New(prefix+"type must be webhook, smtp or command, found: "+notification.Type))
+	}
+
+	if notification.On != "" && notification.On != "failure" && notification.On != "success" && notification.On != "always" {
+		errs = append(errs, errors.New(prefix+"on must be failure, success or always, found: "+notification.On))
+	}
+	for _, event := range notification.Events {
+		if event != "dump" && event != "transfer" && event != "delete" && event != "restore" {
+			errs = append(errs, errors.New(prefix+"unknown event: "+event))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// readable checks that the file of the setting exists and can be read
+func readable(setting string, filename string) error {
+
+	fd, err := os.Open(filename)
+	if err != nil {
+		return errors.New(setting + ": " + err.Error())
+	}
+	return fd.Close()
+}
@@ -0,0 +1,138 @@
+// Craig Tomkow
+// October 18, 2026
+
+package conf
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// a valid sender config, backing up to a tto receiver
+func validSender(t *testing.T) *Config {
+
+	key := filepath.Join(t.TempDir(), "id_rsa")
+	if err := ioutil.WriteFile(key, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	if err := ioutil.WriteFile(knownHosts, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := new(Config)
+	conf.MakeConfig()
+	conf.System.Type = "sender"
+	conf.System.SSHkey = key
+	conf.System.KnownHosts = knownHosts
+	conf.System.Role.Sender.Transfer = "sftp"
+	conf.System.Role.Sender.Compression = "zstd"
+	conf.System.Role.Sender.DestinationPolicy = "any"
+	conf.System.Role.Sender.Cron = "0 0 * * * *"
+	return conf
+}
+
+func TestConfig_ValidateSender(t *testing.T) {
+
+	conf := validSender(t)
+	if err := conf.Validate(); err != nil {
+		t.Errorf("Validate sender test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	conf.System.Role.Sender.Cron = "a cron statement"
+	conf.System.Role.Sender.MaxBackups = 0
+	conf.System.Role.Sender.Compression = "lz4"
+	conf.System.SSHkey = "/nonexistent/id_rsa"
+	conf.System.KnownHosts = "/nonexistent/known_hosts"
+	err := conf.Validate()
+	if err == nil {
+		t.Fatalf("Validate sender test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	// all errors are reported at once
+	for _, expected := range []string{"cron", "max_backups", "compression", "ssh_key", "known_hosts"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate sender test failed; found, expected: %q, an error about %s", err.Error(), expected)
+		}
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 5 {
+		t.Errorf("Validate sender test failed; found, expected: %d, %d errors", len(lines), 5)
+	}
+
+	// with tofu, known_hosts is created on first connect
+	conf.System.TOFU = true
+	if err = conf.Validate(); strings.Contains(err.Error(), "known_hosts") {
+		t.Errorf("Validate sender tofu test failed; found, expected: %q, %s", err.Error(), "no known_hosts error")
+	}
+}
+
+func TestConfig_ValidateDestinations(t *testing.T) {
+
+	conf := validSender(t)
+	conf.System.SSHkey = ""
+	conf.System.Role.Sender.Destinations = []Destination{
+		{Type: "local", Path: "/backups", MaxBackups: 3},
+		{Type: "s3", Bucket: "backups", MaxBackups: 3},
+		{Type: "ftp", MaxBackups: 3},
+	}
+	conf.System.Role.Sender.Database = "mysql"
+	conf.System.Role.Sender.Binlogs = true
+
+	// without ssh destinations, the ssh key isn't needed
+	err := conf.Validate()
+	if err == nil || strings.Contains(err.Error(), "ssh_key") {
+		t.Fatalf("Validate destinations test failed; found, expected: %#v, %s", err, "destination errors only")
+	}
+	if !strings.Contains(err.Error(), "s3 destination needs an endpoint") || !strings.Contains(err.Error(), "unknown destination type: ftp") {
+		t.Errorf("Validate destinations test failed; found, expected: %q, %s", err.Error(), "s3 and ftp errors")
+	}
+	if !strings.Contains(err.Error(), "binlogs need an ssh destination") {
+		t.Errorf("Validate destinations test failed; found, expected: %q, %s", err.Error(), "binlogs error")
+	}
+}
+
+func TestConfig_ValidateReceiver(t *testing.T) {
+
+	conf := new(Config)
+	conf.MakeConfig()
+	conf.System.Type = "receiver"
+	conf.System.Role.Receiver.RestoreMode = "atomic"
+	conf.System.Role.Receiver.RestoreEngine = "driver"
+	if err := conf.Validate(); err != nil {
+		t.Errorf("Validate receiver test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	conf.System.Role.Receiver.Jobs = []ReceiverJob{{DBname: "databaseName"}, {DBname: "databaseName", Database: "oracle"}}
+	conf.System.Role.Receiver.ExecAfter = nil
+	conf.System.Role.Receiver.RestoreEngine = "cli"
+	err := conf.Validate()
+	if err == nil {
+		t.Fatalf("Validate receiver test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	for _, expected := range []string{"restored more than once", "database must be mysql or postgres, found: oracle", "exec_after", "restore_mode atomic needs restore_engine driver"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate receiver test failed; found, expected: %q, %s", err.Error(), expected)
+		}
+	}
+}
+
+func TestConfig_ValidateSystem(t *testing.T) {
+
+	conf := new(Config)
+	conf.MakeConfig()
+	conf.System.WorkingDir = "/opt/tto"
+	conf.System.LogFormat = "xml"
+	conf.System.HTTPListen = "9469"
+	conf.System.Notifications = []Notification{{Type: "smtp", Server: "mail.example.com", On: "never"}}
+
+	err := conf.Validate()
+	if err == nil {
+		t.Fatalf("Validate system test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	for _, expected := range []string{"working_dir", "log_format", "http_listen", "notification 1: smtp server", "notification 1: smtp needs from and to", "notification 1: on", "type must be sender or receiver"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate system test failed; found, expected: %q, %s", err.Error(), expected)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Craig Tomkow
+// October 18, 2026
+
+package db
+
+import (
+	"bufio"
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Binlogs is a database whose binary logs are shipped next to its dumps, for point-in-time recovery.
+// Dumps record the binary log position they were taken at, see BinlogPosition
+type Binlogs interface {
+	DB
+
+	// record the binary log position in the dumps
+	RecordBinlogPosition()
+
+	// close the current binary log, so it can be shipped
+	FlushBinlogs() error
+
+	// return the binary logs of the server, oldest first. The last one is still being written to
+	ListBinlogs() ([]string, error)
+
+	// copy the binary log from the server into dir, returns the path of the copy
+	FetchBinlog(exe *exec.Exec, name string, dir string) (string, error)
+
+	// apply the changes of the binary logs to the database, from position in the first one up to stop
+	ReplayBinlogs(exe *exec.Exec, files []string, position uint64, stop time.Time) error
+}
+
+// mysqldump --master-data=2 records e.g. -- CHANGE MASTER TO MASTER_LOG_FILE='binlog.000042', MASTER_LOG_POS=157;
+// newer servers write CHANGE REPLICATION SOURCE TO SOURCE_LOG_FILE=... instead
+var binlogPosition = regexp.MustCompile(`(?:MASTER|SOURCE)_LOG_FILE='([^']+)',\s*(?:MASTER|SOURCE)_LOG_POS=(\d+)`)
+
+// the position is written in the header of the dump, ahead of any data
+const binlogPositionLines = 100
+
+// BinlogPosition returns the binary log and position a dump was taken at, read from the header of the dump
+func BinlogPosition(dump io.Reader) (string, uint64, error) {
+
+	scanner := bufio.NewScanner(dump)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for i := 0; i < binlogPositionLines && scanner.Scan(); i++ {
+		match := binlogPosition.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		position, err := strconv.ParseUint(match[2], 10, 64)
+		if err != nil {
+			return "", 0, err
+		}
+		return match[1], position, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", 0, err
+	}
+
+	return "", 0, errors.New("no binary log position found in the dump, was it taken with binlogs enabled?")
+}
+
+// dumps record the binary log position, which needs the RELOAD privilege
+func (db *Mysql) RecordBinlogPosition() {
+	db.binlogPosition = true
+}
+
+// close the current binary log and start a new one
+func (db *Mysql) FlushBinlogs() error {
+	_, err := db.connection.Exec("FLUSH BINARY LOGS")
+	return err
+}
+
+// return the binary logs of the server, the columns of SHOW BINARY LOGS differ between versions
+func (db *Mysql) ListBinlogs() ([]string, error) {
+
+	rows, err := db.connection.Query("SHOW BINARY LOGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var binlogs []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		var name string
+		values[0] = &name
+		for i := 1; i < len(values); i++ {
+			values[i] = new(interface{})
+		}
+		if err = rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		binlogs = append(binlogs, name)
+	}
+
+	return binlogs, rows.Err()
+}
+
+// copy the binary log as is from the server with mysqlbinlog. The copy is named dir + name
+func (db *Mysql) FetchBinlog(exe *exec.Exec, name string, dir string) (string, error) {
+
+	ipArg := "-h" + db.ip.String()
+	portArg := "-P" + strconv.FormatUint(uint64(db.port), 10)
+	userArg := "-u" + db.user
+
+	args := []string{"mysqlbinlog", "--read-from-remote-server", "--raw", "--result-file=" + dir, ipArg, portArg, userArg, name}
+	if err := exe.LocalCmdStdin(args, []string{"MYSQL_PWD=" + db.pass}, nil); err != nil {
+		return "", err
+	}
+
+	return dir + name, nil
+}
+
+// decode the binary logs with mysqlbinlog and stream the changes to the database into the mysql client.
+// Only changes to this database are applied. stop is passed in local time, as mysqlbinlog expects
+func (db *Mysql) ReplayBinlogs(exe *exec.Exec, files []string, position uint64, stop time.Time) error {
+
+	args := []string{
+		"mysqlbinlog",
+		"--start-position=" + strconv.FormatUint(position, 10),
+		"--stop-datetime=" + stop.Local().Format("2006-01-02 15:04:05"),
+		"--database=" + db.name,
+		"--skip-gtids",
+	}
+	args = append(args, files...)
+
+	decoder := new(exec.Exec)
+	decoder.LocalCmdOnly(args)
+	stdout, err := decoder.Cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = decoder.Cmd.Start(); err != nil {
+		return err
+	}
+
+	if err = db.RestoreCli(exe, stdout); err != nil {
+		decoder.Kill()
+		return err
+	}
+
+	return decoder.Wait()
+}
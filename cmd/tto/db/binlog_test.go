@@ -0,0 +1,71 @@
+// Craig Tomkow
+// October 18, 2026
+
+package db
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBinlogPosition(t *testing.T) {
+
+	var tests = []struct {
+		header   string
+		file     string
+		position uint64
+	}{
+		{"-- MySQL dump 10.13\n--\n-- CHANGE MASTER TO MASTER_LOG_FILE='binlog.000042', MASTER_LOG_POS=157;\n", "binlog.000042", 157},
+		{"-- CHANGE REPLICATION SOURCE TO SOURCE_LOG_FILE='mysql-bin.000007', SOURCE_LOG_POS=4;\n", "mysql-bin.000007", 4},
+		{"-- MySQL dump 10.13\nCREATE TABLE t (id int);\n", "", 0},
+	}
+
+	for _, test := range tests {
+		file, position, err := BinlogPosition(strings.NewReader(test.header))
+		if test.file == "" {
+			if err == nil {
+				t.Errorf("Binlog position test failed; found, expected: %#v, %s", err, "no position err")
+			}
+			continue
+		}
+		if err != nil || file != test.file || position != test.position {
+			t.Errorf("Binlog position test failed; found, expected: %s %d %#v, %s %d", file, position, err, test.file, test.position)
+		}
+	}
+}
+
+func TestMysql_ReplayBinlogs(t *testing.T) {
+
+	dir := newFakeMysqlClient(t, "exit 0")
+	decoder := "#!/bin/sh\necho \"$@\" > " + dir + "/binlog_args\necho 'INSERT INTO t VALUES (1);'\n"
+	if err := os.WriteFile(filepath.Join(dir, "mysqlbinlog"), []byte(decoder), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	my := NewMysql("mysql", net.IPAddr{IP: net.IPv4(8, 8, 8, 8)}, 3306, "tto", "secret", "databaseName", 1)
+	stop := time.Date(2019, 8, 2, 12, 30, 0, 0, time.UTC)
+	if err := my.ReplayBinlogs(new(exec.Exec), []string{"/tmp/binlog.000042", "/tmp/binlog.000043"}, 157, stop); err != nil {
+		t.Fatalf("Mysql binlog replay test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "binlog_args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "--start-position=157 --stop-datetime=" + stop.Local().Format("2006-01-02 15:04:05") + " --database=databaseName --skip-gtids /tmp/binlog.000042 /tmp/binlog.000043\n"
+	if string(args) != expected {
+		t.Errorf("Mysql binlog replay test failed; found, expected: %q, %q", args, expected)
+	}
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stdin) != "INSERT INTO t VALUES (1);\n" {
+		t.Errorf("Mysql binlog replay test failed; found, expected: %q, %q", stdin, "INSERT INTO t VALUES (1);\n")
+	}
+}
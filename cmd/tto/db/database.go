@@ -19,12 +19,18 @@ type DB interface {
 	// drop database
 	Drop() error
 
-	// dump the database with the command line utility
+	// dump the database with the command line utility. A failed or incomplete dump surfaces as an error at the end of the stream
 	Dump(exe *exec.Exec) (*io.ReadCloser, error)
 
 	// restore the database using the database driver
 	Restore(reader *bufio.Reader) error
 
+	// restore the database all or nothing, a failed restore leaves the current data intact
+	RestoreAtomic(reader *bufio.Reader) error
+
+	// restore the database by streaming the dump into the command line client
+	RestoreCli(exe *exec.Exec, reader io.Reader) error
+
 	// return the implementation type
 	Impl() string
 
@@ -0,0 +1,67 @@
+// 2019 Craig Tomkow
+
+package db
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"io"
+	"strings"
+)
+
+// how much of the end of a dump is kept around to look for the trailer
+const tailSize = 512
+
+// dumpReader wraps the stdout of a dump process. Instead of a plain io.EOF, the end of the stream reports
+// a failed dump: a non-zero exit status (with stderr) or a missing trailer, e.g. when the dump was cut short
+type dumpReader struct {
+	stdout  io.ReadCloser
+	exe     *exec.Exec
+	trailer string
+	tail    []byte
+	err     error
+}
+
+func newDumpReader(stdout io.ReadCloser, exe *exec.Exec, trailer string) *dumpReader {
+	return &dumpReader{stdout: stdout, exe: exe, trailer: trailer}
+}
+
+func (dr *dumpReader) Read(p []byte) (int, error) {
+
+	if dr.err != nil {
+		return 0, dr.err
+	}
+
+	n, err := dr.stdout.Read(p)
+	dr.tail = append(dr.tail, p[:n]...)
+	if len(dr.tail) > tailSize {
+		dr.tail = dr.tail[len(dr.tail)-tailSize:]
+	}
+
+	if err == io.EOF {
+		dr.err = dr.finish()
+		return n, dr.err
+	}
+	if err != nil {
+		dr.err = err
+	}
+
+	return n, err
+}
+
+// once stdout is drained, collect the exit status and look for the trailer
+func (dr *dumpReader) finish() error {
+
+	if err := dr.exe.Wait(); err != nil {
+		return err
+	}
+	if !strings.Contains(string(dr.tail), dr.trailer) {
+		return errors.New("incomplete dump: trailer '" + dr.trailer + "' not found")
+	}
+
+	return io.EOF
+}
+
+func (dr *dumpReader) Close() error {
+	return dr.stdout.Close()
+}
@@ -0,0 +1,56 @@
+// Craig Tomkow
+// October 18, 2026
+
+package db
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var testDumps = []struct {
+	script   string
+	expected string
+}{
+	// complete dump
+	{"echo 'CREATE TABLE t (id int);'; echo '-- Dump completed on 2019-08-02 12:00:00'", ""},
+	// mysqldump died halfway
+	{"echo 'CREATE TABLE t (id int);'; echo 'mysqldump: Error 2013: Lost connection' >&2; exit 2", "Lost connection"},
+	// exited cleanly, but the trailer is missing
+	{"echo 'CREATE TABLE t (id int);'", "incomplete dump"},
+}
+
+func TestDumpReader(t *testing.T) {
+
+	for _, test := range testDumps {
+
+		exe := new(exec.Exec)
+		exe.LocalCmdOnly([]string{"sh", "-c", test.script})
+		stdout, err := exe.Cmd.StdoutPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = exe.Cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		dump, err := ioutil.ReadAll(newDumpReader(stdout, exe, "-- Dump completed"))
+		if !strings.HasPrefix(string(dump), "CREATE TABLE t (id int);") {
+			t.Errorf("Dump reader test failed; found, expected: %q, %q", dump, "CREATE TABLE t (id int);...")
+		}
+
+		switch {
+		case test.expected == "" && err != nil:
+			t.Errorf("Dump reader test failed; found, expected: %#v, %s", err, "nil err")
+		case test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)):
+			t.Errorf("Dump reader test failed; found, expected: %#v, %s", err, test.expected)
+		}
+
+		// the exit status is kept for anyone else waiting on the dump
+		if waitErr := exe.Wait(); (waitErr == nil) != (test.expected == "" || test.expected == "incomplete dump") {
+			t.Errorf("Dump reader test failed; found, expected wait err: %#v", waitErr)
+		}
+	}
+}
@@ -5,7 +5,9 @@ package db
 import (
 	"bufio"
 	"database/sql"
+	"errors"
 	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
 	"github.com/ctomkow/tto/cmd/tto/util"
 	_ "github.com/go-sql-driver/mysql"
 	"io"
@@ -33,6 +35,9 @@ type Mysql struct {
 	// used for mysqldump
 	cmd      *exec.Exec
 	filename string
+
+	// record the binary log position in the dump, for point-in-time recovery
+	binlogPosition bool
 }
 
 // instantiate a new mysql struct
@@ -90,6 +95,16 @@ func (db *Mysql) Drop() error {
 	return nil
 }
 
+// drop the database if it exists, for the scratch databases of an atomic restore
+func (db *Mysql) dropIfExists() error {
+	_, err := db.connection.Exec("DROP DATABASE IF EXISTS " + db.name + ";")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // dump the database and return the stdout stream
 func (db *Mysql) Dump(exe *exec.Exec) (*io.ReadCloser, error) {
 	db.filename = db.name + "_-_" + util.NewTimestamp().Timestamp() + ".sql"
@@ -99,7 +114,12 @@ func (db *Mysql) Dump(exe *exec.Exec) (*io.ReadCloser, error) {
 	userArg := "-u" + db.user
 	passArg := "-p" + db.pass
 
-	exe.LocalCmdOnly([]string{"mysqldump", "--single-transaction", "--skip-lock-tables", "--routines", "--triggers", ipArg, portArg, userArg, passArg, db.name})
+	args := []string{"mysqldump", "--single-transaction", "--skip-lock-tables", "--routines", "--triggers"}
+	if db.binlogPosition {
+		// commented out, the position is only read back for point-in-time recovery
+		args = append(args, "--master-data=2")
+	}
+	exe.LocalCmdOnly(append(args, ipArg, portArg, userArg, passArg, db.name))
 
 	stdout, err := exe.Cmd.StdoutPipe()
 	if err != nil {
@@ -110,44 +130,323 @@ func (db *Mysql) Dump(exe *exec.Exec) (*io.ReadCloser, error) {
 		return nil, err
 	}
 
-	return &stdout, nil
+	// mysqldump ends a complete dump with '-- Dump completed on <date>'
+	var dump io.ReadCloser = newDumpReader(stdout, exe, "-- Dump completed")
+	return &dump, nil
 }
 
 // Read database dump statement by statement and fire off to the database
-// Note: bufio.NewScanner has a line length limit of 65536 chars. db dump does only one INSERT per table
-// Using ReadString with a ';' delimiter, ensuring that the next character after is '\n'
 func (db *Mysql) Restore(reader *bufio.Reader) error {
-	var buf strings.Builder
+	splitter := newMysqlSplitter(reader)
 	for {
-		statement, err := reader.ReadString(';')
+		statement, err := splitter.Next()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
+			return err
 		}
 
-		buf.WriteString(statement)
+		if _, err = db.connection.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		// look at next byte
-		nextByte, err := reader.Peek(1)
-		if err != nil {
+// Stream the dump into the mysql client. It is faster than the driver and handles the session variables of the dump header
+func (db *Mysql) RestoreCli(exe *exec.Exec, reader io.Reader) error {
+
+	// the password is passed through the environment to keep it out of the process list
+	return exe.LocalCmdStdin(db.restoreArgs(), []string{"MYSQL_PWD=" + db.pass}, reader)
+}
+
+// build the mysql client command line
+func (db *Mysql) restoreArgs() []string {
+
+	ipArg := "-h" + db.ip.String()
+	portArg := "-P" + strconv.FormatUint(uint64(db.port), 10)
+	userArg := "-u" + db.user
+
+	return []string{"mysql", "--batch", ipArg, portArg, userArg, db.name}
+}
+
+// Restore the dump into a scratch database, validate it and swap its tables in with a single RENAME TABLE.
+// Readers never see a partially restored database, and a failed restore leaves the current tables untouched.
+// Only base tables are swapped, dumps with views are refused. Triggers can't be renamed to another database and
+// routines belong to the database, so both are recreated from their definitions right after the swap, not atomically
+// with it
+func (db *Mysql) RestoreAtomic(reader *bufio.Reader) error {
+	scratch := db.sibling(db.name + "_tto_scratch")
+	old := db.sibling(db.name + "_tto_old")
+
+	// clean up leftovers of an earlier failed restore
+	if err := scratch.dropIfExists(); err != nil {
+		return err
+	}
+	if err := scratch.Create(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := scratch.dropIfExists(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	// restore over a single connection to the scratch database. Dumps rely on session variables
+	restorer := db.sibling(scratch.name)
+	if err := restorer.Open(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := restorer.connection.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+	if err := restorer.Restore(reader); err != nil {
+		return err
+	}
+
+	scratchTables, err := db.validate(scratch.name)
+	if err != nil {
+		return err
+	}
+	liveTables, err := db.tables(db.name, "BASE TABLE")
+	if err != nil {
+		return err
+	}
+	scratchDefinitions, err := db.definitions(scratch.name)
+	if err != nil {
+		return err
+	}
+	liveDefinitions, err := db.definitions(db.name)
+	if err != nil {
+		return err
+	}
+
+	// the current tables are moved aside and dropped once the swap succeeded
+	if err = old.dropIfExists(); err != nil {
+		return err
+	}
+	if err = old.Create(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := old.dropIfExists(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	// tables with triggers can't be renamed to another database
+	if err = db.dropDefinitions(scratch.name, scratchDefinitions, "TRIGGER"); err != nil {
+		return err
+	}
+	if err = db.dropDefinitions(db.name, liveDefinitions, "TRIGGER"); err != nil {
+		return err
+	}
+
+	if _, err = db.connection.Exec(renameStatement(db.name, scratch.name, old.name, liveTables, scratchTables)); err != nil {
+		// the current tables are still in place, put their triggers back
+		if createErr := db.createDefinitions(liveDefinitions, "TRIGGER"); createErr != nil {
+			logging.Error(createErr, logging.Fields{})
+		}
+		return err
+	}
+
+	for _, kind := range []string{"PROCEDURE", "FUNCTION"} {
+		if err = db.dropDefinitions(db.name, liveDefinitions, kind); err != nil {
+			return err
+		}
+	}
+	for _, kind := range []string{"PROCEDURE", "FUNCTION", "TRIGGER"} {
+		if err = db.createDefinitions(scratchDefinitions, kind); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// a database on the same server, sharing this connection until it is opened itself
+func (db *Mysql) sibling(name string) *Mysql {
+	sibling := NewMysql(db.impl, db.ip, db.port, db.user, db.pass, name, 1)
+	sibling.connection = db.connection
+	return sibling
+}
+
+// validate checks the restored scratch database can be swapped in and returns its tables
+func (db *Mysql) validate(name string) ([]string, error) {
+
+	tables, err := db.tables(name, "BASE TABLE")
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("restored database is empty, refusing to swap it in")
+	}
+
+	views, err := db.tables(name, "VIEW")
+	if err != nil {
+		return nil, err
+	}
+	if len(views) != 0 {
+		return nil, errors.New("atomic restore does not support views: " + strings.Join(views, ", "))
+	}
+
+	return tables, nil
+}
+
+// a trigger or stored routine of a database, with the statement that creates it
+type definition struct {
+	kind   string // TRIGGER, PROCEDURE or FUNCTION
+	name   string
+	create string
+}
+
+// list the triggers and stored routines of a database, along with their definitions
+func (db *Mysql) definitions(name string) ([]definition, error) {
+
+	// triggers of the same table and event fire in the order they were created in
+	rows, err := db.connection.Query("SELECT 'TRIGGER', TRIGGER_NAME, ACTION_ORDER FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = ? "+
+		"UNION ALL SELECT ROUTINE_TYPE, ROUTINE_NAME, 0 FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ? ORDER BY 3", name, name)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []definition
+	for rows.Next() {
+		var def definition
+		var order int
+		if err = rows.Scan(&def.kind, &def.name, &order); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		definitions = append(definitions, def)
+	}
+	if err = rows.Close(); err != nil {
+		return nil, err
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, def := range definitions {
+		if definitions[i].create, err = db.showCreate("SHOW CREATE " + def.kind + " " + quoteName(name, def.name)); err != nil {
+			return nil, err
+		}
+	}
+
+	return definitions, nil
+}
+
+// return the create statement of SHOW CREATE TRIGGER, PROCEDURE or FUNCTION, it is the third column of each
+func (db *Mysql) showCreate(statement string) (string, error) {
+
+	rows, err := db.connection.Query(statement)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() || len(columns) < 3 {
+		return "", errors.New("no definition found: " + statement)
+	}
+	values := make([]sql.NullString, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err = rows.Scan(pointers...); err != nil {
+		return "", err
+	}
+	if !values[2].Valid {
+		return "", errors.New("no privilege to read the definition: " + statement)
+	}
+
+	return values[2].String, nil
+}
+
+// drop the definitions of a kind from the database
+func (db *Mysql) dropDefinitions(name string, definitions []definition, kind string) error {
+
+	for _, def := range definitions {
+		if def.kind != kind {
+			continue
+		}
+		if _, err := db.connection.Exec("DROP " + def.kind + " " + quoteName(name, def.name)); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		// newline '\n' aka utf decimal '10'
-		if nextByte[0] == 10 {
-			_, err = db.connection.Exec(buf.String())
-			if err != nil {
-				return err
-			}
-			buf.Reset()
-		} else {
+// create the definitions of a kind in this database. Their statements aren't database qualified, the connection's
+// database is used
+func (db *Mysql) createDefinitions(definitions []definition, kind string) error {
+
+	for _, def := range definitions {
+		if def.kind != kind {
 			continue
 		}
+		if _, err := db.connection.Exec(def.create); err != nil {
+			return errors.New("recreating " + strings.ToLower(def.kind) + " " + def.name + ": " + err.Error())
+		}
 	}
 	return nil
 }
 
+// list the tables of a database by type, BASE TABLE or VIEW
+func (db *Mysql) tables(name string, tableType string) ([]string, error) {
+
+	rows, err := db.connection.Query("SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = ?", name, tableType)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// renameStatement moves the live tables aside and the scratch tables into place. MySQL executes a multi-table
+// RENAME TABLE atomically, if any rename fails all of them are reverted
+func renameStatement(live string, scratch string, old string, liveTables []string, scratchTables []string) string {
+
+	var renames []string
+	for _, table := range liveTables {
+		renames = append(renames, quoteName(live, table)+" TO "+quoteName(old, table))
+	}
+	for _, table := range scratchTables {
+		renames = append(renames, quoteName(scratch, table)+" TO "+quoteName(live, table))
+	}
+
+	return "RENAME TABLE " + strings.Join(renames, ", ")
+}
+
+// quote a database qualified table name
+func quoteName(database string, table string) string {
+	return "`" + strings.Replace(database, "`", "``", -1) + "`.`" + strings.Replace(table, "`", "``", -1) + "`"
+}
+
 // return implementation type
 func (db *Mysql) Impl() string {
 	return db.impl
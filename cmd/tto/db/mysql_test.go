@@ -0,0 +1,115 @@
+// Craig Tomkow
+// October 18, 2026
+
+package db
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameStatement(t *testing.T) {
+
+	statement := renameStatement("shop", "shop_tto_scratch", "shop_tto_old", []string{"orders", "legacy"}, []string{"orders", "odd`name"})
+
+	expected := "RENAME TABLE `shop`.`orders` TO `shop_tto_old`.`orders`, `shop`.`legacy` TO `shop_tto_old`.`legacy`, " +
+		"`shop_tto_scratch`.`orders` TO `shop`.`orders`, `shop_tto_scratch`.`odd``name` TO `shop`.`odd``name`"
+	if statement != expected {
+		t.Errorf("Rename statement test failed; found, expected: %s, %s", statement, expected)
+	}
+}
+
+func TestRenameStatement_EmptyLive(t *testing.T) {
+
+	statement := renameStatement("shop", "shop_tto_scratch", "shop_tto_old", nil, []string{"orders"})
+
+	expected := "RENAME TABLE `shop_tto_scratch`.`orders` TO `shop`.`orders`"
+	if statement != expected {
+		t.Errorf("Rename statement test failed; found, expected: %s, %s", statement, expected)
+	}
+}
+
+// put a mysql client stand-in on the PATH. It records its arguments, environment and stdin
+func newFakeMysqlClient(t *testing.T, script string) string {
+
+	dir := t.TempDir()
+	fake := "#!/bin/sh\necho \"$@\" > " + dir + "/args\necho \"$MYSQL_PWD\" > " + dir + "/pwd\ncat > " + dir + "/stdin\n" + script + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "mysql"), []byte(fake), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestMysql_RestoreCli(t *testing.T) {
+
+	dir := newFakeMysqlClient(t, "exit 0")
+	my := NewMysql("mysql", net.IPAddr{IP: net.IPv4(8, 8, 8, 8)}, 3306, "tto", "secret", "databaseName", 1)
+	dump := "SET NAMES utf8mb4;\nINSERT INTO t VALUES ('a;\nb');\n"
+
+	if err := my.RestoreCli(new(exec.Exec), strings.NewReader(dump)); err != nil {
+		t.Fatalf("Mysql cli restore test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	files := map[string]string{
+		"args":  "--batch -h8.8.8.8 -P3306 -utto databaseName\n",
+		"pwd":   "secret\n",
+		"stdin": dump,
+	}
+	for file, expected := range files {
+		found, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(found) != expected {
+			t.Errorf("Mysql cli restore test failed; found, expected %s: %q, %q", file, found, expected)
+		}
+	}
+}
+
+func TestMysql_RestoreCliError(t *testing.T) {
+
+	newFakeMysqlClient(t, "echo 'ERROR 1064 (42000) at line 2: You have an error in your SQL syntax' >&2; exit 1")
+	my := NewMysql("mysql", net.IPAddr{IP: net.IPv4(8, 8, 8, 8)}, 3306, "tto", "secret", "databaseName", 1)
+
+	err := my.RestoreCli(new(exec.Exec), strings.NewReader("SELEC 1;\n"))
+	if err == nil || !strings.Contains(err.Error(), "ERROR 1064") {
+		t.Errorf("Mysql cli restore error test failed; found, expected: %#v, %s", err, "ERROR 1064 ...")
+	}
+}
+
+func TestMysql_Definitions(t *testing.T) {
+
+	// the postgres stand-in records the statements, they aren't parsed
+	pg := newFakePostgres(t)
+	mysql := NewMysql("mysql", net.IPAddr{}, 3306, "username", "password", "shop", 1)
+	mysql.connection = pg.connection
+
+	definitions := []definition{
+		{kind: "TRIGGER", name: "audit", create: "CREATE TRIGGER `audit` AFTER INSERT ON `orders` FOR EACH ROW SET @n = 1"},
+		{kind: "PROCEDURE", name: "report", create: "CREATE PROCEDURE `report`() SELECT 1"},
+	}
+	if err := mysql.dropDefinitions("shop_tto_scratch", definitions, "TRIGGER"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mysql.createDefinitions(definitions, "PROCEDURE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mysql.Drop(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"DROP TRIGGER `shop_tto_scratch`.`audit`", "CREATE PROCEDURE `report`() SELECT 1", "DROP DATABASE shop;"}
+	if strings.Join(fakePg.executed, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Mysql definitions test failed; found, expected: %q, %q", fakePg.executed, expected)
+	}
+
+	fakePg.failOn = "CREATE TRIGGER"
+	if err := mysql.createDefinitions(definitions, "TRIGGER"); err == nil || !strings.Contains(err.Error(), "audit") {
+		t.Errorf("Mysql definitions error test failed; found, expected: %#v, %s", err, "recreating trigger audit err")
+	}
+}
@@ -0,0 +1,188 @@
+// 2019 Craig Tomkow
+
+package db
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// mysqlSplitter reads a mysqldump stream statement by statement. It understands
+//   - quoted strings ('...' and "..."), with backslash escapes and doubled quotes
+//   - quoted identifiers (`...`)
+//   - line comments (-- and #) and block comments, including conditional comments (/*!40101 ... */)
+//   - DELIMITER changes, as emitted for --routines and --triggers
+//
+// Line comments are dropped. Block comments are kept, MySQL executes the contents of conditional comments
+type mysqlSplitter struct {
+	reader    *bufio.Reader
+	delimiter string
+}
+
+func newMysqlSplitter(reader *bufio.Reader) *mysqlSplitter {
+	return &mysqlSplitter{reader: reader, delimiter: ";"}
+}
+
+// Next returns the next statement without its delimiter. Returns io.EOF once the dump is exhausted
+func (s *mysqlSplitter) Next() (string, error) {
+	var buf strings.Builder
+
+	for {
+		c, err := s.reader.ReadByte()
+		if err == io.EOF {
+			if statement := strings.TrimSpace(buf.String()); statement != "" {
+				return statement, nil
+			}
+			return "", io.EOF
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			buf.WriteByte(c)
+			if err = s.quoted(&buf, c); err != nil {
+				return "", err
+			}
+			continue
+
+		case c == '#':
+			if err = s.skipLine(); err != nil && err != io.EOF {
+				return "", err
+			}
+			continue
+
+		case c == '-' && s.peekIs("-") && s.lineCommentDash():
+			if err = s.skipLine(); err != nil && err != io.EOF {
+				return "", err
+			}
+			continue
+
+		case c == '/' && s.peekIs("*"):
+			buf.WriteByte(c)
+			if err = s.blockComment(&buf); err != nil {
+				return "", err
+			}
+			continue
+
+		case (c == 'D' || c == 'd') && s.peekIs("ELIMITER") && s.delimiterCommand() && strings.TrimSpace(buf.String()) == "":
+			// DELIMITER is a client command, it is never sent to the server
+			line, err := s.reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return "", err
+			}
+			fields := strings.Fields(line[len("ELIMITER"):])
+			if len(fields) == 0 {
+				return "", errors.New("DELIMITER without a delimiter")
+			}
+			s.delimiter = fields[0]
+			buf.Reset()
+			continue
+		}
+
+		buf.WriteByte(c)
+
+		if c == s.delimiter[len(s.delimiter)-1] && strings.HasSuffix(buf.String(), s.delimiter) {
+			statement := strings.TrimSpace(strings.TrimSuffix(buf.String(), s.delimiter))
+			if statement == "" {
+				buf.Reset()
+				continue
+			}
+			return statement, nil
+		}
+	}
+}
+
+// copy a quoted string or identifier, up to and including the closing quote
+func (s *mysqlSplitter) quoted(buf *strings.Builder, quote byte) error {
+	for {
+		c, err := s.reader.ReadByte()
+		if err == io.EOF {
+			return errors.New("unterminated " + string(quote) + " quote at end of dump")
+		}
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(c)
+
+		// backslash escapes only apply to strings, not identifiers
+		if c == '\\' && quote != '`' {
+			escaped, err := s.reader.ReadByte()
+			if err != nil {
+				return errors.New("unterminated " + string(quote) + " quote at end of dump")
+			}
+			buf.WriteByte(escaped)
+			continue
+		}
+
+		if c == quote {
+			// a doubled quote is an escaped quote
+			if s.peekIs(string(quote)) {
+				next, _ := s.reader.ReadByte()
+				buf.WriteByte(next)
+				continue
+			}
+			return nil
+		}
+	}
+}
+
+// copy a block comment, the opening '/' is already copied
+func (s *mysqlSplitter) blockComment(buf *strings.Builder) error {
+	star, _ := s.reader.ReadByte()
+	buf.WriteByte(star)
+
+	var prev byte
+	for {
+		c, err := s.reader.ReadByte()
+		if err == io.EOF {
+			return errors.New("unterminated comment at end of dump")
+		}
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(c)
+
+		if prev == '*' && c == '/' {
+			return nil
+		}
+		prev = c
+	}
+}
+
+// skip to the end of the line
+func (s *mysqlSplitter) skipLine() error {
+	_, err := s.reader.ReadString('\n')
+	return err
+}
+
+// a '--' only starts a comment when followed by whitespace (or the end of the dump)
+func (s *mysqlSplitter) lineCommentDash() bool {
+	next, err := s.reader.Peek(2)
+	if err != nil {
+		return len(next) == 1
+	}
+	return next[1] == ' ' || next[1] == '\t' || next[1] == '\n' || next[1] == '\r'
+}
+
+// DELIMITER must be followed by whitespace to be the client command
+func (s *mysqlSplitter) delimiterCommand() bool {
+	next, err := s.reader.Peek(len("ELIMITER") + 1)
+	if err != nil {
+		return false
+	}
+	last := next[len(next)-1]
+	return last == ' ' || last == '\t'
+}
+
+// peekIs reports whether the upcoming bytes match str, case-insensitive
+func (s *mysqlSplitter) peekIs(str string) bool {
+	next, err := s.reader.Peek(len(str))
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(string(next), str)
+}
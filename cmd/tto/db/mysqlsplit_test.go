@@ -0,0 +1,142 @@
+// Craig Tomkow
+// October 18, 2026
+
+package db
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func splitAll(t testing.TB, dump string) ([]string, error) {
+
+	splitter := newMysqlSplitter(bufio.NewReader(strings.NewReader(dump)))
+
+	var statements []string
+	for {
+		statement, err := splitter.Next()
+		if err == io.EOF {
+			return statements, nil
+		}
+		if err != nil {
+			return statements, err
+		}
+		statements = append(statements, statement)
+	}
+}
+
+// expected statements of testdata/mysqldump.sql, a trimmed down mysqldump --routines --triggers
+var expectedMysqlStatements = []string{
+	"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */",
+	"/*!50503 SET NAMES utf8mb4 */",
+	"/*!40014 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0 */",
+	"DROP TABLE IF EXISTS `notes`",
+	"/*!40101 SET @saved_cs_client     = @@character_set_client */",
+	"CREATE TABLE `notes` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  `body` text,\n  `odd;name` varchar(10) DEFAULT 'a;b',\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=4 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci",
+	"/*!40101 SET character_set_client = @saved_cs_client */",
+	"LOCK TABLES `notes` WRITE",
+	"/*!40000 ALTER TABLE `notes` DISABLE KEYS */",
+	"INSERT INTO `notes` VALUES (1,'semicolon;\\nnewline in a string;\\n','x'),(2,'it\\'s -- not a comment','y'),(3,'back\\\\slash; # not a comment either','z')",
+	"/*!40000 ALTER TABLE `notes` ENABLE KEYS */",
+	"UNLOCK TABLES",
+	"/*!50003 SET @saved_cs_client      = @@character_set_client */",
+	"/*!50003 SET sql_mode              = 'ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES' */",
+	"/*!50003 CREATE*/ /*!50017 DEFINER=`root`@`localhost`*/ /*!50003 TRIGGER `notes_bi` BEFORE INSERT ON `notes` FOR EACH ROW BEGIN\n  SET NEW.body = TRIM(NEW.body);\n  SET NEW.`odd;name` = 'trimmed;';\nEND */",
+	"/*!50003 SET sql_mode              = @saved_sql_mode */",
+	"/*!50003 DROP PROCEDURE IF EXISTS `count_notes` */",
+	"CREATE DEFINER=`root`@`localhost` PROCEDURE `count_notes`(OUT total INT)\nBEGIN\n    SELECT COUNT(*) INTO total FROM notes WHERE body <> ';;';\nEND",
+	"/*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */",
+}
+
+func TestMysqlSplitter_Mysqldump(t *testing.T) {
+
+	dump, err := os.ReadFile("testdata/mysqldump.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements, err := splitAll(t, string(dump))
+	if err != nil {
+		t.Fatalf("Mysql splitter test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	if len(statements) != len(expectedMysqlStatements) {
+		t.Fatalf("Mysql splitter test failed; found, expected: %d, %d statements\n%q", len(statements), len(expectedMysqlStatements), statements)
+	}
+	for i, statement := range expectedMysqlStatements {
+		if statements[i] != statement {
+			t.Errorf("Mysql splitter test failed; found, expected:\n%q\n%q", statements[i], statement)
+		}
+	}
+}
+
+var testSplits = []struct {
+	input    string
+	expected []string
+	err      bool
+}{
+	// the old splitter broke on ';\n' inside a string
+	{"INSERT INTO t VALUES ('a;\nb');\nSELECT 1;\n", []string{"INSERT INTO t VALUES ('a;\nb')", "SELECT 1"}, false},
+	{"SELECT 'it''s';SELECT \"dq\"\"x;\";", []string{"SELECT 'it''s'", "SELECT \"dq\"\"x;\""}, false},
+	{"SELECT `a``;b` FROM t;", []string{"SELECT `a``;b` FROM t"}, false},
+	{"SELECT 1 -- trailing comment; still a comment\n;", []string{"SELECT 1"}, false},
+	{"SELECT 5--1;", []string{"SELECT 5--1"}, false},
+	{"SELECT /* not; the end */ 1;", []string{"SELECT /* not; the end */ 1"}, false},
+	{"delimiter $$\nSELECT 1; SELECT 2$$\ndelimiter ;\nSELECT 3;", []string{"SELECT 1; SELECT 2", "SELECT 3"}, false},
+	{";;\n;  ;\n", nil, false},
+	{"SELECT 1", []string{"SELECT 1"}, false},
+	{"SELECT 'unterminated;", nil, true},
+	{"SELECT 1 /* unterminated", nil, true},
+}
+
+func TestMysqlSplitter_Table(t *testing.T) {
+
+	for _, test := range testSplits {
+
+		statements, err := splitAll(t, test.input)
+		if (err != nil) != test.err {
+			t.Errorf("Mysql splitter table test failed for %q; found, expected: %#v, err %t", test.input, err, test.err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if strings.Join(statements, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("Mysql splitter table test failed for %q; found, expected: %q, %q", test.input, statements, test.expected)
+		}
+	}
+}
+
+func FuzzMysqlSplitter(f *testing.F) {
+
+	dump, err := os.ReadFile("testdata/mysqldump.sql")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(string(dump))
+	for _, test := range testSplits {
+		f.Add(test.input)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		statements, err := splitAll(t, input)
+		if err != nil {
+			return
+		}
+
+		// every statement is made of input bytes, so there can't be more text out than in
+		var total int
+		for _, statement := range statements {
+			if statement == "" {
+				t.Errorf("Mysql splitter fuzz test failed; found empty statement for %q", input)
+			}
+			total += len(statement)
+		}
+		if total > len(input) {
+			t.Errorf("Mysql splitter fuzz test failed; found, expected: %d, <= %d bytes", total, len(input))
+		}
+	})
+}
@@ -4,9 +4,19 @@
 package db
 
 import (
+	"bufio"
 	"database/sql"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/util"
+	_ "github.com/lib/pq"
+	"io"
 	"net"
-	"os/exec"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 type Postgres struct {
@@ -25,31 +35,318 @@ type Postgres struct {
 	name    string
 	maxConn int
 
-	// used for postgresdump
-	cmd      *exec.Cmd
+	// used for pg_dump
+	cmd      *exec.Exec
 	filename string
 }
 
 // instantiate a new postgres struct
-func NewPostgres() {}
+func NewPostgres(impl string, ip net.IPAddr, port uint16, user string, pass string, name string, maxConn int) *Postgres {
+
+	return &Postgres{
+		connection: nil,
+		impl:       impl,
+		ip:         ip,
+		port:       port,
+		user:       user,
+		pass:       pass,
+		name:       name,
+		maxConn:    maxConn,
+		cmd:        nil,
+	}
+}
 
 // connect to database and ensure it is reachable
-func (db *Postgres) Open() {}
+func (db *Postgres) Open() error {
+	conn, err := sql.Open(db.impl, db.dsn(db.name))
+	if err != nil {
+		return err
+	}
+
+	conn.SetMaxOpenConns(db.maxConn)
+
+	err = conn.Ping()
+	if err != nil {
+		return err
+	}
+
+	db.connection = conn
+
+	return nil
+}
+
+// dsn builds the connection URL to the named database. Building it as a URL escapes the user, password and name
+func (db *Postgres) dsn(name string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(db.user, db.pass),
+		Host:     net.JoinHostPort(db.ip.String(), strconv.FormatUint(uint64(db.port), 10)),
+		Path:     "/" + name,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
 
 // create the database
-func (db *Postgres) Create() {}
+func (db *Postgres) Create() error {
+	return db.maintenance("CREATE DATABASE " + db.name + ";")
+}
 
 // drop the database
-func (db *Postgres) Drop() {}
+func (db *Postgres) Drop() error {
+	return db.maintenance("DROP DATABASE IF EXISTS " + db.name + ";")
+}
+
+// maintenance runs the statement on the postgres maintenance database. Postgres can't create or drop the database
+// it is connected to
+func (db *Postgres) maintenance(statement string) error {
+	conn, err := sql.Open(db.impl, db.dsn("postgres"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	_, err = conn.Exec(statement)
+	return err
+}
 
 // dump the database and return the stdout stream
-func (db *Postgres) Dump() {}
+func (db *Postgres) Dump(exe *exec.Exec) (*io.ReadCloser, error) {
+	db.filename = db.name + "_-_" + util.NewTimestamp().Timestamp() + ".sql"
+
+	exe.LocalCmdOnly(db.dumpArgs())
 
-// restore database
-func (db *Postgres) Restore() {}
+	// pg_dump has no password argument, it is passed through the environment instead
+	exe.Cmd.Env = append(os.Environ(), "PGPASSWORD="+db.pass)
+
+	stdout, err := exe.Cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = exe.Cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// pg_dump ends a complete dump with '-- PostgreSQL database dump complete'
+	var dump io.ReadCloser = newDumpReader(stdout, exe, "-- PostgreSQL database dump complete")
+	return &dump, nil
+}
+
+// build the pg_dump command line
+// --inserts produces plain statements instead of COPY blocks so the dump can be restored through the database driver
+// --clean --if-exists drops existing objects on restore, similar to mysqldump's DROP TABLE IF EXISTS
+func (db *Postgres) dumpArgs() []string {
+
+	hostArg := "--host=" + db.ip.String()
+	portArg := "--port=" + strconv.FormatUint(uint64(db.port), 10)
+	userArg := "--username=" + db.user
+
+	return []string{"pg_dump", "--no-password", "--inserts", "--clean", "--if-exists", "--no-owner", "--no-privileges", hostArg, portArg, userArg, db.name}
+}
+
+// Read database dump statement by statement and fire off to the database
+func (db *Postgres) Restore(reader *bufio.Reader) error {
+	return restorePostgres(db.connection, reader)
+}
+
+// Postgres DDL is transactional, so the whole dump is restored in a single transaction
+func (db *Postgres) RestoreAtomic(reader *bufio.Reader) error {
+	tx, err := db.connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = restorePostgres(tx, reader); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logging.Error(rbErr, logging.Fields{})
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Stream the dump into psql, stopping at the first error
+func (db *Postgres) RestoreCli(exe *exec.Exec, reader io.Reader) error {
+	return exe.LocalCmdStdin(db.restoreArgs(), []string{"PGPASSWORD=" + db.pass}, reader)
+}
+
+// build the psql command line
+func (db *Postgres) restoreArgs() []string {
+
+	hostArg := "--host=" + db.ip.String()
+	portArg := "--port=" + strconv.FormatUint(uint64(db.port), 10)
+	userArg := "--username=" + db.user
+	dbArg := "--dbname=" + db.name
+
+	return []string{"psql", "--no-password", "--quiet", "--set=ON_ERROR_STOP=1", hostArg, portArg, userArg, dbArg}
+}
+
+// either a *sql.DB or a *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func restorePostgres(conn execer, reader *bufio.Reader) error {
+	for {
+		statement, err := nextPostgresStatement(reader)
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if statement != "" {
+			if _, execErr := conn.Exec(statement); execErr != nil {
+				return execErr
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return nil
+}
 
 // return implementation type
-func (db *Postgres) Impl() {}
+func (db *Postgres) Impl() string {
+	return db.impl
+}
 
 // return database name
-func (db *Postgres) Name() {}
+func (db *Postgres) Name() string {
+	return db.name
+}
+
+// return dump filename
+func (db *Postgres) DumpName() string {
+	return db.filename
+}
+
+// nextPostgresStatement reads the next complete statement from a pg_dump plain text dump.
+// A ';' only terminates a statement outside of quotes, dollar quotes and comments.
+// psql meta-commands (lines starting with '\', e.g. \restrict) are skipped, they are not SQL.
+// Returns io.EOF together with any trailing statement once the reader is exhausted
+func nextPostgresStatement(reader *bufio.Reader) (string, error) {
+	var buf strings.Builder
+	var dollarTag string
+	var dollarBody int
+	inSingle, inDouble, inLineComment, inBlockComment := false, false, false, false
+
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return strings.TrimSpace(buf.String()), err
+		}
+
+		switch {
+		case inLineComment:
+			if r == '\n' {
+				inLineComment = false
+			}
+			continue
+
+		case inBlockComment:
+			if r == '*' && peekRune(reader) == '/' {
+				_, _, _ = reader.ReadRune()
+				inBlockComment = false
+			}
+			continue
+
+		case inSingle:
+			buf.WriteRune(r)
+			if r == '\'' {
+				inSingle = false
+			}
+			continue
+
+		case inDouble:
+			buf.WriteRune(r)
+			if r == '"' {
+				inDouble = false
+			}
+			continue
+
+		case dollarTag != "":
+			buf.WriteRune(r)
+			if r == '$' && buf.Len()-dollarBody >= len(dollarTag) && strings.HasSuffix(buf.String(), dollarTag) {
+				dollarTag = ""
+			}
+			continue
+		}
+
+		switch r {
+		case '\\':
+			if strings.TrimSpace(buf.String()) == "" {
+				if _, err := reader.ReadString('\n'); err != nil {
+					return "", err
+				}
+				continue
+			}
+		case '-':
+			if peekRune(reader) == '-' {
+				inLineComment = true
+				continue
+			}
+		case '/':
+			if peekRune(reader) == '*' {
+				_, _, _ = reader.ReadRune()
+				inBlockComment = true
+				continue
+			}
+		case '\'':
+			inSingle = true
+		case '"':
+			inDouble = true
+		case '$':
+			if tag, ok := readDollarTag(reader); ok {
+				buf.WriteString(tag)
+				dollarTag = tag
+				dollarBody = buf.Len()
+				continue
+			}
+		case ';':
+			statement := strings.TrimSpace(buf.String())
+			if statement == "" {
+				continue
+			}
+			return statement, nil
+		}
+
+		buf.WriteRune(r)
+	}
+}
+
+// peekRune returns the next rune without consuming it, or 0 if there is none
+func peekRune(reader *bufio.Reader) rune {
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return 0
+	}
+	_ = reader.UnreadRune()
+	return r
+}
+
+// readDollarTag is called after a '$' was read. If the following runes complete a dollar quote opening ($$ or $tag$)
+// they are consumed and the full tag is returned. Otherwise nothing is consumed (e.g. positional parameters like $1)
+func readDollarTag(reader *bufio.Reader) (string, bool) {
+	for i := 1; ; i++ {
+		peeked, err := reader.Peek(i)
+		if err != nil {
+			return "", false
+		}
+		c := rune(peeked[i-1])
+		if c == '$' {
+			tag := "$" + string(peeked)
+			_, _ = reader.Discard(i)
+			return tag, true
+		}
+		if !(c == '_' || unicode.IsLetter(c) || (i > 1 && unicode.IsDigit(c))) {
+			return "", false
+		}
+	}
+}
@@ -5,14 +5,28 @@ package exec
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"github.com/ctomkow/tto/cmd/tto/inet"
+	"io"
+	"os"
 	"os/exec"
+	"strings"
+	"sync"
 )
 
 type Exec struct {
 
 	// currently executing command
 	Cmd *exec.Cmd
+
+	// stderr of the currently executing command
+	stderr bytes.Buffer
+
+	// exit status of the currently executing command, once it has been waited on
+	mu      sync.Mutex
+	waited  bool
+	waitErr error
 }
 
 func (c *Exec) RemoteCmd(ssh *inet.SSH, command string) (string, error) {
@@ -48,5 +62,68 @@ func (c *Exec) LocalCmd(command []string) (string, error) {
 
 // set pointer to the running command. Mainly used for streaming database dumps
 func (c *Exec) LocalCmdOnly(command []string) {
-	c.Cmd = exec.Command(command[0], command[1:]...)
+	c.setCmd(exec.Command(command[0], command[1:]...))
+}
+
+func (c *Exec) setCmd(cmd *exec.Cmd) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Cmd = cmd
+	c.stderr.Reset()
+	c.Cmd.Stderr = &c.stderr
+	c.waited = false
+	c.waitErr = nil
+}
+
+// LocalCmdStdin runs the command to completion with stdin streamed in, e.g. to restore a dump with a database client.
+// env is added to the current environment. A non-zero exit status is returned as an error that includes stderr
+func (c *Exec) LocalCmdStdin(command []string, env []string, stdin io.Reader) error {
+
+	return c.LocalCmdStdinContext(context.Background(), command, env, stdin)
+}
+
+// LocalCmdStdinContext is LocalCmdStdin, killing the command once ctx is done, e.g. at a deadline
+func (c *Exec) LocalCmdStdinContext(ctx context.Context, command []string, env []string, stdin io.Reader) error {
+
+	c.setCmd(exec.CommandContext(ctx, command[0], command[1:]...))
+	c.Cmd.Env = append(os.Environ(), env...)
+	c.Cmd.Stdin = stdin
+
+	if err := c.Cmd.Start(); err != nil {
+		return err
+	}
+
+	return c.Wait()
+}
+
+// Wait waits for the command set by LocalCmdOnly to exit. It is safe to call more than once.
+// A non-zero exit status is returned as an error that includes the command's stderr
+func (c *Exec) Wait() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.waited {
+		return c.waitErr
+	}
+	c.waited = true
+
+	if err := c.Cmd.Wait(); err != nil {
+		c.waitErr = errors.New(c.Cmd.Path + ": " + err.Error() + ": " + strings.TrimSpace(c.stderr.String()))
+	}
+
+	return c.waitErr
+}
+
+// Kill stops the command set by LocalCmdOnly if it is still running, e.g. when nothing reads its output anymore
+func (c *Exec) Kill() {
+	c.mu.Lock()
+	running := c.Cmd != nil && c.Cmd.Process != nil && !c.waited
+	c.mu.Unlock()
+
+	if !running {
+		return
+	}
+	_ = c.Cmd.Process.Kill()
+	_ = c.Wait()
 }
@@ -0,0 +1,38 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/metrics"
+	"net"
+	"net/http"
+)
+
+// serveHTTP starts the optional HTTP listener of the daemon, serving /metrics to Prometheus and the status API
+func serveHTTP(conf *conf.Config) error {
+
+	if conf.System.HTTPListen == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", conf.System.HTTPListen)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	statusHandlers(conf, mux)
+
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+	logging.Info("serving metrics and status on "+listener.Addr().String(), logging.Fields{Event: logging.Config})
+
+	return nil
+}
@@ -0,0 +1,147 @@
+// Craig Tomkow
+// October 18, 2026
+
+package inet
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"golang.org/x/crypto/ssh"
+	hk "golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// HostKeyError is returned when the remote presents a host key that differs from the known or pinned key
+type HostKeyError struct {
+	Host        string
+	Fingerprint string
+}
+
+func (e *HostKeyError) Error() string {
+	return "host key mismatch for " + e.Host + ", remote presented " + e.Fingerprint +
+		". Possible man-in-the-middle attack! If the key legitimately changed, update the known_hosts file or the pinned host_key"
+}
+
+// IsHostKeyError reports whether err was caused by a host key mismatch
+func IsHostKeyError(err error) bool {
+	var keyErr *HostKeyError
+	return errors.As(err, &keyErr)
+}
+
+// hostKeyVerifier checks remote host keys against a pinned fingerprint and/or a known_hosts file.
+// With trust on first use, the key of a host that is not in known_hosts yet is recorded instead of rejected
+type hostKeyVerifier struct {
+	knownHostsFile string
+	fingerprint    string
+	tofu           bool
+
+	// known_hosts callback, reloaded after a key is recorded
+	mu       sync.Mutex
+	callback ssh.HostKeyCallback
+}
+
+func newHostKeyVerifier(knownHostsFile string, fingerprint string, tofu bool) (*hostKeyVerifier, error) {
+
+	v := &hostKeyVerifier{
+		knownHostsFile: knownHostsFile,
+		fingerprint:    fingerprint,
+		tofu:           tofu,
+	}
+
+	// a pinned fingerprint takes precedence over known_hosts
+	if v.fingerprint != "" {
+		return v, nil
+	}
+
+	if tofu {
+		if err := os.MkdirAll(filepath.Dir(v.knownHostsFile), 0700); err != nil {
+			return nil, err
+		}
+		fd, err := os.OpenFile(v.knownHostsFile, os.O_CREATE|os.O_RDONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if err = fd.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := v.load(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// (re)read the known_hosts file
+func (v *hostKeyVerifier) load() error {
+
+	callback, err := hk.New(v.knownHostsFile)
+	if err != nil {
+		return err
+	}
+	v.callback = callback
+
+	return nil
+}
+
+// Check satisfies ssh.HostKeyCallback
+func (v *hostKeyVerifier) Check(hostname string, remote net.Addr, key ssh.PublicKey) error {
+
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	if v.fingerprint != "" {
+		if fingerprint != v.fingerprint {
+			return &HostKeyError{Host: hostname, Fingerprint: fingerprint}
+		}
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	err := v.callback(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+
+	var keyErr *hk.KeyError
+	if !errors.As(err, &keyErr) {
+		return err
+	}
+
+	// the host is known, but with a different key
+	if len(keyErr.Want) != 0 {
+		return &HostKeyError{Host: hostname, Fingerprint: fingerprint}
+	}
+
+	// the host is unknown
+	if !v.tofu {
+		return errors.New("unknown host " + hostname + " (" + fingerprint + "), add it to " + v.knownHostsFile + " or enable trust_on_first_use")
+	}
+	if err = v.record(hostname, key); err != nil {
+		return err
+	}
+	logging.Info("trust on first use: recorded host key for "+hostname+": "+fingerprint, logging.Fields{Event: logging.Connection, Destination: hostname})
+
+	return v.load()
+}
+
+// append the host key to the known_hosts file
+func (v *hostKeyVerifier) record(hostname string, key ssh.PublicKey) error {
+
+	fd, err := os.OpenFile(v.knownHostsFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = fd.WriteString(hk.Line([]string{hostname}, key) + "\n"); err != nil {
+		_ = fd.Close()
+		return err
+	}
+
+	return fd.Close()
+}
@@ -0,0 +1,130 @@
+// Craig Tomkow
+// October 18, 2026
+
+package inet
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"golang.org/x/crypto/ssh"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testRemote = &net.TCPAddr{IP: net.IPv4(6, 6, 6, 6), Port: 22}
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestHostKeyVerifier_Pinned(t *testing.T) {
+
+	key := newTestHostKey(t)
+	otherKey := newTestHostKey(t)
+
+	v, err := newHostKeyVerifier("", ssh.FingerprintSHA256(key), false)
+	if err != nil {
+		t.Fatalf("Pinned host key test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	if err = v.Check("6.6.6.6:22", testRemote, key); err != nil {
+		t.Errorf("Pinned host key test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if err = v.Check("6.6.6.6:22", testRemote, otherKey); !IsHostKeyError(err) {
+		t.Errorf("Pinned host key test failed; found, expected: %#v, %s", err, "host key error")
+	}
+}
+
+func TestHostKeyVerifier_KnownHosts(t *testing.T) {
+
+	key := newTestHostKey(t)
+	otherKey := newTestHostKey(t)
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	if err := ioutil.WriteFile(knownHosts, []byte("6.6.6.6 "+string(ssh.MarshalAuthorizedKey(key))), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := newHostKeyVerifier(knownHosts, "", false)
+	if err != nil {
+		t.Fatalf("Known hosts test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	if err = v.Check("6.6.6.6:22", testRemote, key); err != nil {
+		t.Errorf("Known hosts test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if err = v.Check("6.6.6.6:22", testRemote, otherKey); !IsHostKeyError(err) {
+		t.Errorf("Known hosts test failed; found, expected: %#v, %s", err, "host key error")
+	}
+
+	// unknown host without trust on first use
+	err = v.Check("7.7.7.7:22", testRemote, key)
+	if err == nil || IsHostKeyError(err) {
+		t.Errorf("Known hosts test failed; found, expected: %#v, %s", err, "unknown host error")
+	}
+}
+
+func TestHostKeyVerifier_TOFU(t *testing.T) {
+
+	key := newTestHostKey(t)
+	otherKey := newTestHostKey(t)
+
+	// the file and its directory don't exist yet
+	knownHosts := filepath.Join(t.TempDir(), ".ssh", "known_hosts")
+
+	v, err := newHostKeyVerifier(knownHosts, "", true)
+	if err != nil {
+		t.Fatalf("TOFU test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	if err = v.Check("6.6.6.6:2222", testRemote, key); err != nil {
+		t.Fatalf("TOFU test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	contents, err := ioutil.ReadFile(knownHosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(contents), "[6.6.6.6]:2222 ssh-ed25519 ") {
+		t.Errorf("TOFU test failed; found, expected: %s, %s", contents, "[6.6.6.6]:2222 ssh-ed25519 ...")
+	}
+
+	// the recorded key is accepted, a different one is rejected
+	if err = v.Check("6.6.6.6:2222", testRemote, key); err != nil {
+		t.Errorf("TOFU test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if err = v.Check("6.6.6.6:2222", testRemote, otherKey); !IsHostKeyError(err) {
+		t.Errorf("TOFU test failed; found, expected: %#v, %s", err, "host key error")
+	}
+
+	// a new verifier (daemon restart) still knows the host
+	v, err = newHostKeyVerifier(knownHosts, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = v.Check("6.6.6.6:2222", testRemote, otherKey); !IsHostKeyError(err) {
+		t.Errorf("TOFU test failed; found, expected: %#v, %s", err, "host key error")
+	}
+}
+
+func TestDefaultKnownHosts(t *testing.T) {
+
+	t.Setenv("HOME", "/root")
+
+	found, err := DefaultKnownHosts()
+	if err != nil || found != "/root/.ssh/known_hosts" {
+		t.Errorf("Default known hosts test failed; found, expected: %s %#v, %s", found, err, "/root/.ssh/known_hosts")
+	}
+}
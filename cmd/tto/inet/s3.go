@@ -0,0 +1,153 @@
+// Craig Tomkow
+// October 18, 2026
+
+package inet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 is a bucket of an S3-compatible object storage, e.g. AWS S3 or MinIO.
+// Objects are stored under prefix, which acts as the working directory of the bucket
+type S3 struct {
+	endpoint  string
+	region    string
+	accessKey string
+	secretKey string
+	bucket    string
+	prefix    string
+	client    *minio.Client
+}
+
+// endpoint is a url, e.g. https://s3.amazonaws.com or http://minio.local:9000. An empty region defaults to us-east-1
+func (s *S3) Make(endpoint string, region string, accessKey string, secretKey string, bucket string, prefix string) {
+
+	s.endpoint = endpoint
+	s.region = region
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	s.accessKey = accessKey
+	s.secretKey = secretKey
+	s.bucket = bucket
+	s.prefix = prefix
+	if s.prefix != "" && !strings.HasSuffix(s.prefix, "/") {
+		s.prefix += "/"
+	}
+}
+
+func (s *S3) Connect() error {
+
+	endpoint, err := url.Parse(s.endpoint)
+	if err != nil {
+		return err
+	}
+	if endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return errors.New("s3 endpoint must be an http or https url: " + s.endpoint)
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(s.accessKey, s.secretKey, ""),
+		Secure: endpoint.Scheme == "https",
+		Region: s.region,
+	})
+	if err != nil {
+		return err
+	}
+	s.client = client
+
+	return s.TestConnection()
+}
+
+func (s *S3) GetClient() *minio.Client {
+
+	return s.client
+}
+
+// Bucket returns the name of the bucket
+func (s *S3) Bucket() string {
+
+	return s.bucket
+}
+
+// Key returns the object key of a file, e.g. prefix/databaseName_-_20190802120000.sql
+func (s *S3) Key(filename string) string {
+
+	return s.prefix + filename
+}
+
+func (s *S3) String() string {
+
+	return "s3://" + s.bucket + "/" + s.prefix
+}
+
+func (s *S3) TestConnection() error {
+
+	if s.client == nil {
+		return errors.New("not connected to " + s.String())
+	}
+
+	exists, err := s.client.BucketExists(context.Background(), s.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("s3 bucket does not exist: " + s.bucket)
+	}
+
+	return nil
+}
+
+func (s *S3) Reconnect(tries int, delayInSec int) error {
+
+	for i := 1; i <= tries; i++ {
+		logging.Error(errors.New("["+strconv.Itoa(i)+"/"+strconv.Itoa(tries)+"]"+" attempting to re-connect with "+s.String()), logging.Fields{Event: logging.Connection, Destination: s.String()})
+		if err := s.Connect(); err != nil {
+			logging.Error(errors.New("failed to re-establish connection with "+s.String()), logging.Fields{Event: logging.Connection, Destination: s.String()})
+		} else {
+			logging.Info("re-established connection with "+s.String(), logging.Fields{Event: logging.Connection, Destination: s.String()})
+			return nil
+		}
+
+		time.Sleep(time.Duration(delayInSec) * time.Second)
+	}
+
+	return errors.New("reconnection with " + s.String() + " failed")
+}
+
+// List returns the objects under the prefix whose names start with namePrefix. Their keys are returned without the prefix
+func (s *S3) List(namePrefix string) ([]minio.ObjectInfo, error) {
+
+	var objects []minio.ObjectInfo
+	for object := range s.client.ListObjects(context.Background(), s.bucket, minio.ListObjectsOptions{Prefix: s.Key(namePrefix)}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		object.Key = strings.TrimPrefix(object.Key, s.prefix)
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// Put stores a small file, e.g. a checksum manifest
+func (s *S3) Put(filename string, contents string) error {
+
+	_, err := s.client.PutObject(context.Background(), s.bucket, s.Key(filename), bytes.NewReader([]byte(contents)), int64(len(contents)), minio.PutObjectOptions{})
+	return err
+}
+
+// Remove deletes a file, a missing file is not an error
+func (s *S3) Remove(filename string) error {
+
+	return s.client.RemoveObject(context.Background(), s.bucket, s.Key(filename), minio.RemoveObjectOptions{})
+}
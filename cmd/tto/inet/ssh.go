@@ -5,10 +5,11 @@ package inet
 
 import (
 	"errors"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/logging"
 	"golang.org/x/crypto/ssh"
-	//hk "golang.org/x/crypto/ssh/knownhosts"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 )
@@ -18,13 +19,25 @@ type SSH struct {
 	remoteHostPort string
 	user           string
 	pass           string
-	key 		   string
+	key            string
 	config         *ssh.ClientConfig
 	Session        *ssh.Session
 	connection     *ssh.Client
 }
 
-func (sh *SSH) Make(ip string, port string, user string, pass string, key string) {
+// DefaultKnownHosts is the known_hosts file of the local user running tto, e.g. /root/.ssh/known_hosts
+func DefaultKnownHosts() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+// knownHosts is the known_hosts file to verify the remote against, hostKey an optional pinned SHA256 fingerprint.
+// With tofu, an unknown remote has its key recorded in knownHosts on first connect. A key or known_hosts that can't be
+// read is returned as an error
+func (sh *SSH) Make(ip string, port string, user string, pass string, key string, knownHosts string, hostKey string, tofu bool) error {
 
 	sh.remoteHostName = ip
 	sh.remoteHostPort = port
@@ -34,16 +47,21 @@ func (sh *SSH) Make(ip string, port string, user string, pass string, key string
 
 	keyContents, err := sh.readKey()
 	if err != nil {
-		glog.Fatal(err)
+		return errors.New("ssh_key: " + err.Error())
 	}
 	signer, err := ssh.ParsePrivateKeyWithPassphrase(keyContents, []byte(sh.pass))
 	if err != nil {
-		glog.Fatal(err)
+		return errors.New("ssh_key " + key + ": " + err.Error())
 	}
 
-	//hostKeyCallback, err := hk.New("/home/"+sh.user+"/.ssh/known_hosts")
+	if knownHosts == "" {
+		if knownHosts, err = DefaultKnownHosts(); err != nil {
+			return errors.New("known_hosts: " + err.Error())
+		}
+	}
+	verifier, err := newHostKeyVerifier(knownHosts, hostKey, tofu)
 	if err != nil {
-		glog.Fatal(err)
+		return errors.New("known_hosts: " + err.Error())
 	}
 
 	sh.config = &ssh.ClientConfig{
@@ -51,8 +69,10 @@ func (sh *SSH) Make(ip string, port string, user string, pass string, key string
 		Auth: []ssh.AuthMethod{
 			ssh.PublicKeys(signer),
 		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: verifier.Check,
 	}
+
+	return nil
 }
 
 func (sh *SSH) Connect() error {
@@ -68,6 +88,11 @@ func (sh *SSH) Connect() error {
 
 func (sh *SSH) NewSession() error {
 
+	// the remote was down from the start
+	if sh.connection == nil {
+		return errors.New("not connected to " + sh.String())
+	}
+
 	var err error
 	sh.Session, err = sh.connection.NewSession()
 	if err != nil {
@@ -98,6 +123,16 @@ func (sh *SSH) GetSession() *ssh.Session {
 	return sh.Session
 }
 
+func (sh *SSH) GetClient() *ssh.Client {
+
+	return sh.connection
+}
+
+func (sh *SSH) String() string {
+
+	return sh.remoteHostName
+}
+
 func (sh *SSH) TestConnection() error {
 
 	if err := sh.NewSession(); err != nil {
@@ -113,11 +148,15 @@ func (sh *SSH) TestConnection() error {
 func (sh *SSH) Reconnect(tries int, delayInSec int) error {
 
 	for i := 1; i <= tries; i++ {
-		glog.Error("[" + strconv.Itoa(i) + "/" + strconv.Itoa(tries) + "]" + " attempting to re-connect with remote")
+		logging.Error(errors.New("["+strconv.Itoa(i)+"/"+strconv.Itoa(tries)+"]"+" attempting to re-connect with remote"), logging.Fields{Event: logging.Connection, Destination: sh.String()})
 		if err := sh.Connect(); err != nil {
-			glog.Error("failed to re-establish connection with remote")
+			// a changed host key will not fix itself by retrying
+			if IsHostKeyError(err) {
+				return err
+			}
+			logging.Error(errors.New("failed to re-establish connection with remote"), logging.Fields{Event: logging.Connection, Destination: sh.String()})
 		} else {
-			glog.Info("re-established connection with remote")
+			logging.Info("re-established connection with remote", logging.Fields{Event: logging.Connection, Destination: sh.String()})
 			return nil
 		}
 
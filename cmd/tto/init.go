@@ -5,99 +5,114 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/backup"
 	"github.com/ctomkow/tto/cmd/tto/conf"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/notify"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"strconv"
 )
 
-func install() {
+func install() error {
 
 	// create config directory if it doesn't exist
 	if err := os.MkdirAll("/etc/tto/", os.ModePerm); err != nil {
-		glog.Fatal(err)
+		return err
 	}
 
 	// if sample conf.json doesn't exist, create it
 	if !fileExists("/etc/tto/conf.json") {
-		fd, err := os.Create("/etc/tto/conf.json")
-		if err != nil {
-			glog.Exit(err)
-		}
-		defer func() {
-			if err := fd.Close(); err != nil {
-				glog.Exit(err)
-			}
-		}()
 
 		// populate with sample configuration
 		var sampleConf = new(conf.Config)
 		sampleConf.MakeConfig()
 
-		var jsonData []byte
-		jsonData, err = json.MarshalIndent(sampleConf, "", "    ")
+		jsonData, err := json.MarshalIndent(sampleConf, "", "    ")
 		if err != nil {
-			glog.Error(err)
+			return err
 		}
-
-		_, err = fd.WriteString(string(jsonData))
-		if err != nil {
-			glog.Error(err)
+		if err = ioutil.WriteFile("/etc/tto/conf.json", jsonData, 0644); err != nil {
+			return err
 		}
-		glog.Info("created file: /etc/tto/conf.json")
+		logging.Info("created file: /etc/tto/conf.json", logging.Fields{Event: logging.Config})
 	}
 
 	// create working directory if it doesn't exist
-	if err := os.MkdirAll("/opt/tto/", os.ModePerm); err != nil {
-		glog.Fatal(err)
+	return os.MkdirAll("/opt/tto/", os.ModePerm)
+}
+
+// trackingFiles returns the .latest.dump and .latest.restore files of each database restored by the receiver.
+// The sender keeps no tracking files of its own
+func trackingFiles(conf *conf.Config) []string {
+
+	if conf.System.Type != "receiver" {
+		return nil
+	}
+
+	var files []string
+	for _, job := range conf.ReceiverJobs() {
+		files = append(files, backup.LatestDump(job.DBname), backup.LatestRestore(job.DBname))
 	}
+	return files
 }
 
-func setupWorkingDir(conf *conf.Config) {
+func setupWorkingDir(conf *conf.Config) error {
 
-	// ensure working directory files exists
-	if !fileExists(conf.System.WorkingDir + ".latest.dump") {
-		_, err := os.Create(conf.System.WorkingDir + ".latest.dump")
-		if err != nil {
-			glog.Exit(err)
+	// carry over the tracking files of a receiver upgraded from a single database release
+	if conf.System.Type == "receiver" {
+		var dbNames []string
+		for _, job := range conf.ReceiverJobs() {
+			dbNames = append(dbNames, job.DBname)
+		}
+		if err := backup.MigrateLatest(conf.System.WorkingDir, dbNames); err != nil {
+			return err
 		}
-		glog.Info("created file: " + conf.System.WorkingDir + ".latest.dump")
 	}
 
 	// ensure working directory files exists
-	if !fileExists(conf.System.WorkingDir + ".latest.restore") {
-		_, err := os.Create(conf.System.WorkingDir + ".latest.restore")
+	for _, file := range trackingFiles(conf) {
+		if fileExists(conf.System.WorkingDir + file) {
+			continue
+		}
+		fd, err := os.Create(conf.System.WorkingDir + file)
 		if err != nil {
-			glog.Exit(err)
+			return err
+		}
+		if err = fd.Close(); err != nil {
+			return err
 		}
-		glog.Info("created file: " + conf.System.WorkingDir + ".latest.restore")
+		logging.Info("created file: "+conf.System.WorkingDir+file, logging.Fields{Event: logging.Config})
 	}
+
+	return nil
 }
 
-func setupPermissions(conf *conf.Config) {
+func setupPermissions(conf *conf.Config) error {
 
 	// chown all files to appropriate usr
 
 	// get app uid/gid based on system.conf from conf.json
 	usr, err := user.Lookup(conf.System.User)
 	if err != nil {
-		glog.Exit(err)
+		return err
 	}
 	uid, _ := strconv.Atoi(usr.Uid)
 	gid, _ := strconv.Atoi(usr.Gid)
 
-	if err = os.Chown("/opt/tto/", uid, gid); err != nil {
-		glog.Exit(err)
+	if err = os.Chown(conf.System.WorkingDir, uid, gid); err != nil {
+		return err
 	}
 
-	if err = os.Chown("/opt/tto/.latest.dump", uid, gid); err != nil {
-		glog.Exit(err)
+	for _, file := range trackingFiles(conf) {
+		if err = os.Chown(conf.System.WorkingDir+file, uid, gid); err != nil {
+			return err
+		}
 	}
 
-	if err = os.Chown("/opt/tto/.latest.restore", uid, gid); err != nil {
-		glog.Exit(err)
-	}
+	return nil
 }
 
 func fileExists(filename string) bool {
@@ -108,3 +123,27 @@ func fileExists(filename string) bool {
 	}
 	return !info.IsDir()
 }
+
+// setupNotifications registers the configured notifiers
+func setupNotifications(conf *conf.Config) error {
+
+	for _, n := range conf.System.Notifications {
+		var notifier notify.Notifier
+		switch n.Type {
+		case "webhook":
+			notifier = notify.NewWebhook(n.URL)
+		case "smtp":
+			notifier = notify.NewSMTP(n.Server, n.Username, n.Password, n.From, n.To)
+		case "command":
+			if len(n.Command) == 0 {
+				return errors.New("command notification has no command")
+			}
+			notifier = notify.NewCommand(n.Command)
+		default:
+			return errors.New("unknown notification type: " + n.Type)
+		}
+		notify.Register(notifier, n.Events, n.On)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+// Craig Tomkow
+// October 18, 2026
+
+// Package logging writes the logs of tto, as glog text by default or as JSON lines with consistent fields,
+// e.g. {"time":"...","level":"info","role":"sender","event":"transfer","db":"databaseName","bytes":1024}
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/golang/glog"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// the events logged about
+const (
+	Dump       = "dump"
+	Transfer   = "transfer"
+	Delete     = "delete"
+	Restore    = "restore"
+	Binlog     = "binlog"
+	Connection = "connection"
+	Config     = "config"
+)
+
+// Fields describe what a log line is about. Empty fields are left out
+type Fields struct {
+	Event       string
+	DB          string
+	Dump        string
+	Destination string
+	Bytes       int64
+	Duration    time.Duration
+}
+
+// a JSON log line
+type record struct {
+	Time        string  `json:"time"`
+	Level       string  `json:"level"`
+	Role        string  `json:"role,omitempty"`
+	Event       string  `json:"event,omitempty"`
+	Msg         string  `json:"msg,omitempty"`
+	DB          string  `json:"db,omitempty"`
+	Dump        string  `json:"dump,omitempty"`
+	Destination string  `json:"destination,omitempty"`
+	Bytes       int64   `json:"bytes,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+var (
+	mu     sync.Mutex
+	asJSON bool
+	role   string
+	out    io.Writer = os.Stderr
+)
+
+// SetFormat logs as "text" (default) or "json". role, sender or receiver, is added to every JSON line
+func SetFormat(format string, r string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch format {
+	case "", "text":
+		asJSON = false
+	case "json":
+		asJSON = true
+	default:
+		return errors.New("unknown log format: " + format)
+	}
+	role = r
+	return nil
+}
+
+// Info logs what happened, e.g. a dump that was transferred
+func Info(msg string, fields Fields) {
+	if !write("info", msg, nil, fields) {
+		glog.InfoDepth(1, msg)
+	}
+}
+
+// Warning logs what was skipped, e.g. a check that can't be done
+func Warning(msg string, fields Fields) {
+	if !write("warning", msg, nil, fields) {
+		glog.WarningDepth(1, msg)
+	}
+}
+
+// Error logs an error that doesn't stop tto, e.g. a failed transfer
+func Error(err error, fields Fields) {
+	if !write("error", "", err, fields) {
+		glog.ErrorDepth(1, err)
+	}
+}
+
+// Flush writes out any buffered text logs, before tto exits
+func Flush() {
+	glog.Flush()
+}
+
+// write the JSON line, if logging as JSON
+func write(level string, msg string, err error, fields Fields) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !asJSON {
+		return false
+	}
+
+	line := record{
+		Time:        time.Now().UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Role:        role,
+		Event:       fields.Event,
+		Msg:         msg,
+		DB:          fields.DB,
+		Dump:        fields.Dump,
+		Destination: fields.Destination,
+		Bytes:       fields.Bytes,
+		Duration:    fields.Duration.Seconds(),
+	}
+	if err != nil {
+		line.Error = err.Error()
+	}
+	encoded, jsonErr := json.Marshal(line)
+	if jsonErr != nil {
+		glog.Error(jsonErr)
+		return false
+	}
+	_, _ = out.Write(append(encoded, '\n'))
+	return true
+}
@@ -0,0 +1,72 @@
+// Craig Tomkow
+// October 18, 2026
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSON(t *testing.T) {
+
+	var buf bytes.Buffer
+	out = &buf
+	defer func() {
+		out = os.Stderr
+		_ = SetFormat("text", "")
+	}()
+	if err := SetFormat("json", "sender"); err != nil {
+		t.Fatal(err)
+	}
+
+	Info("transferred db dump", Fields{Event: Transfer, DB: "databaseName", Dump: "databaseName_-_20190802120000.sql", Destination: "6.6.6.6", Bytes: 1024, Duration: 1500 * time.Millisecond})
+	Error(errors.New("connection lost"), Fields{Event: Connection, Destination: "6.6.6.6"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("JSON log test failed; found, expected: %d, %d lines", len(lines), 2)
+	}
+
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &info); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"level": "info", "role": "sender", "event": "transfer", "msg": "transferred db dump", "db": "databaseName",
+		"dump": "databaseName_-_20190802120000.sql", "destination": "6.6.6.6", "bytes": float64(1024), "duration": 1.5}
+	for key, value := range expected {
+		if info[key] != value {
+			t.Errorf("JSON log field test failed; found, expected: %s %v, %v", key, info[key], value)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, info["time"].(string)); err != nil {
+		t.Errorf("JSON log time test failed; found, expected: %v, an RFC 3339 time", info["time"])
+	}
+
+	var failure map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &failure); err != nil {
+		t.Fatal(err)
+	}
+	if failure["level"] != "error" || failure["error"] != "connection lost" || failure["db"] != nil || failure["bytes"] != nil {
+		t.Errorf("JSON log error test failed; found, expected: %v, an error without empty fields", failure)
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+
+	defer func() { _ = SetFormat("text", "") }()
+
+	for _, format := range []string{"", "text", "json"} {
+		if err := SetFormat(format, "receiver"); err != nil {
+			t.Errorf("Log format test failed; found, expected: %v, %v", err, nil)
+		}
+	}
+	if err := SetFormat("xml", "receiver"); err == nil {
+		t.Errorf("Log format test failed; found, expected: %v, an error", err)
+	}
+}
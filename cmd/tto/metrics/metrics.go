@@ -0,0 +1,159 @@
+// Craig Tomkow
+// October 18, 2026
+
+// Package metrics keeps the gauges and counters of tto and exposes them in the Prometheus text format.
+// Metrics are declared once with Gauge or Counter, then updated by name with their label pairs
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type family struct {
+	help string
+	kind string
+
+	// values by their rendered labels, e.g. {db="databaseName"}
+	values map[string]float64
+}
+
+var (
+	mu       sync.Mutex
+	families = make(map[string]*family)
+)
+
+// Gauge declares a metric that goes up and down, e.g. a timestamp
+func Gauge(name string, help string) {
+	declare(name, help, "gauge")
+}
+
+// Counter declares a metric that only goes up, e.g. bytes transferred
+func Counter(name string, help string) {
+	declare(name, help, "counter")
+}
+
+func declare(name string, help string, kind string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := families[name]; !ok {
+		families[name] = &family{help: help, kind: kind, values: make(map[string]float64)}
+	}
+}
+
+// Set sets the gauge with the label pairs, e.g. Set("tto_remote_alive", 1, "db", "databaseName")
+func Set(name string, value float64, labels ...string) {
+	update(name, labels, func(float64) float64 { return value })
+}
+
+// SetMax sets the gauge only if the value is greater than its current one, e.g. the newest of several timestamps
+func SetMax(name string, value float64, labels ...string) {
+	update(name, labels, func(current float64) float64 { return math.Max(current, value) })
+}
+
+// Add adds delta to the counter with the label pairs
+func Add(name string, delta float64, labels ...string) {
+	update(name, labels, func(current float64) float64 { return current + delta })
+}
+
+// Value returns the current value of the metric with the label pairs, and whether it has been set
+func Value(name string, labels ...string) (float64, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, ok := families[name]
+	if !ok {
+		return 0, false
+	}
+	value, ok := f.values[render(labels)]
+	return value, ok
+}
+
+// updating an undeclared metric is a programming error
+func update(name string, labels []string, fn func(float64) float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, ok := families[name]
+	if !ok {
+		panic("metrics: " + name + " is not declared")
+	}
+	key := render(labels)
+	current, ok := f.values[key]
+	if !ok {
+		current = math.Inf(-1)
+		if f.kind == "counter" {
+			current = 0
+		}
+	}
+	f.values[key] = fn(current)
+}
+
+// render the label pairs, e.g. {db="databaseName",destination="6.6.6.6"}
+func render(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+escape(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escape a label value, as the text format requires: backslash, double quote and line feed
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// escape a HELP text, as the text format requires: backslash and line feed
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// Write writes all metrics that have been set in the Prometheus text format, sorted by name and labels
+func Write(w io.Writer) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var names []string
+	for name, f := range families {
+		if len(f.values) != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := families[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(f.help), name, f.kind); err != nil {
+			return err
+		}
+		var keys []string
+		for key := range f.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", name, key, strconv.FormatFloat(f.values[key], 'f', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Handler serves the metrics to Prometheus
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_ = Write(w)
+	})
+}
@@ -0,0 +1,92 @@
+// Craig Tomkow
+// October 18, 2026
+
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+
+	Gauge("test_last_timestamp_seconds", "Time of the last test.")
+	Counter("test_bytes_total", "Bytes of tests.")
+	Gauge("test_unset", "Never set, not written.")
+
+	Set("test_last_timestamp_seconds", 1564747200, "db", "databaseName")
+	SetMax("test_last_timestamp_seconds", 1564740000, "db", "databaseName")
+	SetMax("test_last_timestamp_seconds", 1.5, "db", `odd"name`)
+	Add("test_bytes_total", 512, "db", "databaseName", "destination", "6.6.6.6")
+	Add("test_bytes_total", 512, "db", "databaseName", "destination", "6.6.6.6")
+
+	var out bytes.Buffer
+	if err := Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP test_bytes_total Bytes of tests.
+# TYPE test_bytes_total counter
+test_bytes_total{db="databaseName",destination="6.6.6.6"} 1024
+# HELP test_last_timestamp_seconds Time of the last test.
+# TYPE test_last_timestamp_seconds gauge
+test_last_timestamp_seconds{db="databaseName"} 1564747200
+test_last_timestamp_seconds{db="odd\"name"} 1.5
+`
+	// the metrics of tto are declared too, only compare the test ones
+	if found := testMetrics(out.String()); found != expected {
+		t.Errorf("Metrics write test failed; found, expected:\n%s\n%s", found, expected)
+	}
+
+	if value, ok := Value("test_bytes_total", "db", "databaseName", "destination", "6.6.6.6"); !ok || value != 1024 {
+		t.Errorf("Metrics value test failed; found, expected: %v %t, %d", value, ok, 1024)
+	}
+	if _, ok := Value("test_unset"); ok {
+		t.Errorf("Metrics value test failed; found, expected: %t, %t", ok, false)
+	}
+}
+
+func TestEscape(t *testing.T) {
+
+	Gauge("test_escape", "Paths like C:\\dumps,\nover two lines.")
+	Set("test_escape", 1, "destination", "C:\\dumps", "db", `odd"name`, "error", "connection\nlost")
+
+	var out bytes.Buffer
+	if err := Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`# HELP test_escape Paths like C:\\dumps,\nover two lines.` + "\n",
+		`test_escape{destination="C:\\dumps",db="odd\"name",error="connection\nlost"} 1` + "\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Metrics escape test failed; found, expected:\n%s\n%s", out.String(), expected)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+
+	Gauge("test_handler", "Served over http.")
+	Set("test_handler", 1)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := ioutil.ReadAll(recorder.Body)
+	if !strings.Contains(string(body), "test_handler 1\n") || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Metrics handler test failed; found, expected: %q, %s", body, "test_handler 1")
+	}
+}
+
+func testMetrics(output string) string {
+	var lines []string
+	for _, line := range strings.SplitAfter(output, "\n") {
+		if strings.Contains(line, "test_bytes_total") || strings.Contains(line, "test_last_timestamp_seconds") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "")
+}
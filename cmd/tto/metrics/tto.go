@@ -0,0 +1,43 @@
+// Craig Tomkow
+// October 18, 2026
+
+package metrics
+
+// the metrics of the sender, labelled by db and destination
+const (
+	BackupLastSuccess   = "tto_backup_last_success_timestamp_seconds"
+	BackupDuration      = "tto_backup_duration_seconds"
+	BackupFailures      = "tto_backup_failures_total"
+	TransferLastSuccess = "tto_transfer_last_success_timestamp_seconds"
+	TransferDuration    = "tto_transfer_duration_seconds"
+	TransferBytes       = "tto_transfer_bytes_total"
+	TransferFailures    = "tto_transfer_failures_total"
+	Backups             = "tto_backups"
+	MaxBackups          = "tto_backups_max"
+	RemoteAlive         = "tto_remote_alive"
+	Reconnects          = "tto_reconnects_total"
+)
+
+// the metrics of the receiver, labelled by db
+const (
+	RestoreLastSuccess = "tto_restore_last_success_timestamp_seconds"
+	RestoreDuration    = "tto_restore_duration_seconds"
+	RestoreFailures    = "tto_restore_failures_total"
+)
+
+func init() {
+	Gauge(BackupLastSuccess, "Time of the last backup that was stored as the destination policy requires.")
+	Gauge(BackupDuration, "Duration of the last successful backup, from the start of the dump to the last destination.")
+	Counter(BackupFailures, "Backups that failed or were skipped.")
+	Gauge(TransferLastSuccess, "Time of the last dump stored at the destination.")
+	Gauge(TransferDuration, "Duration of the last successful transfer to the destination.")
+	Counter(TransferBytes, "Bytes of dumps stored at the destination.")
+	Counter(TransferFailures, "Transfers to the destination that failed.")
+	Gauge(Backups, "Dumps kept by the ring buffer or GFS retention of the destination.")
+	Gauge(MaxBackups, "Dumps the ring buffer of the destination keeps at most.")
+	Gauge(RemoteAlive, "Whether the destination is reachable (1) or backups to it are suspended (0).")
+	Counter(Reconnects, "Attempts to re-establish the connection to the destination.")
+	Gauge(RestoreLastSuccess, "Time of the last successful restore.")
+	Gauge(RestoreDuration, "Duration of the last successful restore.")
+	Counter(RestoreFailures, "Restores that failed.")
+}
@@ -0,0 +1,102 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"compress/gzip"
+	"errors"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"strings"
+)
+
+// CompressionExt returns the file extension appended to a .sql dump for the given compression
+func CompressionExt(compression string) string {
+	switch compression {
+	case "gzip":
+		return ".gz"
+	case "zstd":
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// Compress returns a stream of r compressed with gzip or zstd. Any other compression returns r untouched.
+// The source is fully read and compressed in a goroutine, errors surface on the returned stream
+func Compress(r io.ReadCloser, compression string) (io.ReadCloser, error) {
+
+	if CompressionExt(compression) == "" {
+		return r, nil
+	}
+
+	pr, pw := io.Pipe()
+
+	var cw io.WriteCloser
+	var err error
+	switch compression {
+	case "gzip":
+		cw = gzip.NewWriter(pw)
+	case "zstd":
+		cw, err = zstd.NewWriter(pw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	go func() {
+		if _, err := io.Copy(cw, r); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+		// flush the compressor trailer before signalling EOF
+		if err := cw.Close(); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+		_ = pw.Close()
+	}()
+
+	return pr, nil
+}
+
+// Decompress returns a plain text stream of the dump, based on the extension of its filename
+func Decompress(r io.Reader, filename string) (io.ReadCloser, error) {
+
+	for _, ext := range []string{".sql.gz", ".sql.zst", ".sql"} {
+		if strings.HasSuffix(filename, ext) {
+			return decompress(r, strings.TrimPrefix(ext, ".sql"))
+		}
+	}
+	return nil, errors.New("unknown dump format: " + filename)
+}
+
+// DecompressFile returns the decompressed stream of a file that isn't a dump, e.g. a binary log, based on the
+// extension of its filename. Without a compression extension r is returned untouched
+func DecompressFile(r io.Reader, filename string) (io.ReadCloser, error) {
+
+	for _, ext := range []string{".gz", ".zst"} {
+		if strings.HasSuffix(filename, ext) {
+			return decompress(r, ext)
+		}
+	}
+	return io.NopCloser(r), nil
+}
+
+// decompress r by the extension of its compression, see CompressionExt
+func decompress(r io.Reader, ext string) (io.ReadCloser, error) {
+
+	switch ext {
+	case ".gz":
+		return gzip.NewReader(r)
+	case ".zst":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
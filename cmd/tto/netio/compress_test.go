@@ -0,0 +1,80 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var testCompressions = []struct {
+	compression string
+	filename    string
+}{
+	{"none", "databaseName_-_20190802120000.sql"},
+	{"gzip", "databaseName_-_20190802120000.sql.gz"},
+	{"zstd", "databaseName_-_20190802120000.sql.zst"},
+}
+
+func TestCompress_RoundTrip(t *testing.T) {
+
+	dump := strings.Repeat("INSERT INTO t VALUES (1,'highly compressible');\n", 5000)
+
+	for _, test := range testCompressions {
+
+		if !strings.HasSuffix(test.filename, ".sql"+CompressionExt(test.compression)) {
+			t.Errorf("Compression ext test failed; found, expected: %s, %s", CompressionExt(test.compression), test.filename)
+		}
+
+		compressed, err := Compress(ioutil.NopCloser(strings.NewReader(dump)), test.compression)
+		if err != nil {
+			t.Fatalf("Compress test failed; found, expected: %#v, %s", err, "nil err")
+		}
+		stored, err := ioutil.ReadAll(compressed)
+		if err != nil {
+			t.Fatalf("Compress test failed; found, expected: %#v, %s", err, "nil err")
+		}
+		if test.compression != "none" && len(stored) >= len(dump) {
+			t.Errorf("Compress test failed; %s did not compress: %d >= %d bytes", test.compression, len(stored), len(dump))
+		}
+
+		plain, err := Decompress(strings.NewReader(string(stored)), test.filename)
+		if err != nil {
+			t.Fatalf("Decompress test failed; found, expected: %#v, %s", err, "nil err")
+		}
+		restored, err := ioutil.ReadAll(plain)
+		if err != nil {
+			t.Fatalf("Decompress test failed; found, expected: %#v, %s", err, "nil err")
+		}
+		if string(restored) != dump {
+			t.Errorf("Decompress test failed; %s round trip found, expected: %d, %d bytes", test.compression, len(restored), len(dump))
+		}
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestCompress_SourceError(t *testing.T) {
+
+	compressed, err := Compress(ioutil.NopCloser(failingReader{}), "gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ioutil.ReadAll(compressed); err != io.ErrUnexpectedEOF {
+		t.Errorf("Compress source error test failed; found, expected: %#v, %#v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestDecompress_Unknown(t *testing.T) {
+
+	if _, err := Decompress(strings.NewReader(""), "databaseName_-_20190802120000.sql.bz2"); err == nil {
+		t.Errorf("Decompress unknown test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}
@@ -0,0 +1,108 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"errors"
+	"filippo.io/age"
+	"io"
+	"os"
+	"strings"
+)
+
+// encrypted dumps carry an extra extension, e.g. databaseName_-_20190802120000.sql.gz.age
+const encryptionExt = ".age"
+
+// EncryptionExt returns the file extension appended to a dump when it is encrypted for the given recipients
+func EncryptionExt(recipients []string) string {
+	if len(recipients) == 0 {
+		return ""
+	}
+	return encryptionExt
+}
+
+// Encrypt returns a stream of r encrypted to the age recipients (age1... public keys).
+// Without recipients r is returned untouched. Like Compress, errors surface on the returned stream
+func Encrypt(r io.ReadCloser, recipients []string) (io.ReadCloser, error) {
+
+	if len(recipients) == 0 {
+		return r, nil
+	}
+
+	var parsed []age.Recipient
+	for _, recipient := range recipients {
+		x25519, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, x25519)
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		err := encrypt(pw, r, parsed)
+		// on failure, stop the source too, e.g. a Compress goroutine would otherwise block on a pipe nobody reads
+		closeWithError(r, err)
+		_ = pw.CloseWithError(err)
+	}()
+
+	return pr, nil
+}
+
+func encrypt(w io.Writer, r io.Reader, recipients []age.Recipient) error {
+
+	// the header is written right away, so this must happen once someone is reading
+	ew, err := age.Encrypt(w, recipients...)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(ew, r); err != nil {
+		return err
+	}
+	// the final chunk is only written on close
+	return ew.Close()
+}
+
+// close r, passing err on to the writer at the other end if r is a pipe
+func closeWithError(r io.ReadCloser, err error) {
+
+	if pr, ok := r.(*io.PipeReader); ok {
+		_ = pr.CloseWithError(err)
+		return
+	}
+	_ = r.Close()
+}
+
+// Decrypt returns the decrypted stream of an encrypted dump using the private keys in identityFile,
+// along with the filename stripped of its encryption extension. Unencrypted dumps are returned untouched
+func Decrypt(r io.Reader, filename string, identityFile string) (io.Reader, string, error) {
+
+	if !strings.HasSuffix(filename, encryptionExt) {
+		return r, filename, nil
+	}
+
+	if identityFile == "" {
+		return nil, "", errors.New("dump " + filename + " is encrypted, but no identity_file is configured")
+	}
+
+	fd, err := os.Open(identityFile)
+	if err != nil {
+		return nil, "", err
+	}
+	identities, err := age.ParseIdentities(fd)
+	if closeErr := fd.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	plain, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return plain, strings.TrimSuffix(filename, encryptionExt), nil
+}
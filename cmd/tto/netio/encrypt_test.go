@@ -0,0 +1,136 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"filippo.io/age"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generate a keypair and store the private key as the receiver would
+func newTestIdentity(t *testing.T) (string, string) {
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(t.TempDir(), "identity.txt")
+	if err = ioutil.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return identity.Recipient().String(), identityFile
+}
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+
+	recipient, identityFile := newTestIdentity(t)
+	dump := strings.Repeat("INSERT INTO t VALUES (1,'secret');\n", 5000)
+	filename := "databaseName_-_20190802120000.sql.gz" + EncryptionExt([]string{recipient})
+
+	compressed, err := Compress(ioutil.NopCloser(strings.NewReader(dump)), "gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt(compressed, []string{recipient})
+	if err != nil {
+		t.Fatalf("Encrypt test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	stored, err := ioutil.ReadAll(encrypted)
+	if err != nil {
+		t.Fatalf("Encrypt test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if strings.Contains(string(stored), "secret") {
+		t.Errorf("Encrypt test failed; plaintext found in the encrypted dump")
+	}
+
+	decrypted, plainName, err := Decrypt(strings.NewReader(string(stored)), filename, identityFile)
+	if err != nil {
+		t.Fatalf("Decrypt test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if plainName != "databaseName_-_20190802120000.sql.gz" {
+		t.Errorf("Decrypt test failed; found, expected: %s, %s", plainName, "databaseName_-_20190802120000.sql.gz")
+	}
+	plain, err := Decompress(decrypted, plainName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ioutil.ReadAll(plain)
+	if err != nil {
+		t.Fatalf("Decrypt test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if string(restored) != dump {
+		t.Errorf("Decrypt test failed; found, expected: %d, %d bytes", len(restored), len(dump))
+	}
+}
+
+func TestDecrypt_WrongKey(t *testing.T) {
+
+	recipient, _ := newTestIdentity(t)
+	_, otherIdentityFile := newTestIdentity(t)
+
+	encrypted, err := Encrypt(ioutil.NopCloser(strings.NewReader("-- dump")), []string{recipient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ioutil.ReadAll(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = Decrypt(strings.NewReader(string(stored)), "databaseName_-_20190802120000.sql.age", otherIdentityFile); err == nil {
+		t.Errorf("Decrypt wrong key test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+	if _, _, err = Decrypt(strings.NewReader(string(stored)), "databaseName_-_20190802120000.sql.age", ""); err == nil {
+		t.Errorf("Decrypt no identity test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}
+
+func TestEncrypt_None(t *testing.T) {
+
+	if EncryptionExt(nil) != "" {
+		t.Errorf("Encryption ext test failed; found, expected: %s, %s", EncryptionExt(nil), "")
+	}
+	if _, err := Encrypt(ioutil.NopCloser(strings.NewReader("")), []string{"not a key"}); err == nil {
+		t.Errorf("Encrypt bad recipient test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+
+	_, plainName, err := Decrypt(strings.NewReader(""), "databaseName_-_20190802120000.sql", "")
+	if err != nil || plainName != "databaseName_-_20190802120000.sql" {
+		t.Errorf("Decrypt unencrypted test failed; found, expected: %#v %s, %s", err, plainName, "databaseName_-_20190802120000.sql")
+	}
+}
+
+func TestEncrypt_ReaderGone(t *testing.T) {
+
+	recipient, _ := newTestIdentity(t)
+
+	// the source is a pipe, like the output of Compress
+	source, sourceWriter := io.Pipe()
+	encrypted, err := Encrypt(source, []string{recipient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody reads the encrypted stream anymore, the source must not block forever
+	_ = encrypted.Close()
+	done := make(chan error)
+	go func() {
+		_, err := sourceWriter.Write([]byte("INSERT INTO t VALUES (1);\n"))
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Errorf("Encrypt reader gone test failed; found, expected: %#v, %s", err, "not nil err")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Encrypt reader gone test failed; found, expected: %s, %s", "source blocked", "source closed")
+	}
+}
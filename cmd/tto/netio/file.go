@@ -0,0 +1,53 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// StreamFile writes the dump into a local directory, e.g. an NFS mount.
+// Like StreamSftp, the dump is written to filename as given and synced to disk once the dump process has exited
+// successfully. On any failure the file is removed. Renaming it into place is left to the caller
+func StreamFile(byteBuffer *io.ReadCloser, filename string, dir string, permissions os.FileMode, ex *exec.Exec) error {
+
+	absolutePath := filepath.Join(dir, filename)
+
+	fd, err := os.OpenFile(absolutePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, permissions)
+	if err != nil {
+		return err
+	}
+
+	if err = writeFile(fd, *byteBuffer, ex.Wait); err != nil {
+		_ = fd.Close()
+		if rmErr := os.Remove(absolutePath); rmErr != nil {
+			logging.Error(rmErr, logging.Fields{})
+		}
+		return err
+	}
+
+	if err = fd.Close(); err != nil {
+		_ = os.Remove(absolutePath)
+		return err
+	}
+
+	return nil
+}
+
+func writeFile(fd *os.File, r io.Reader, done func() error) error {
+
+	if _, err := io.Copy(fd, r); err != nil {
+		return err
+	}
+
+	if err := done(); err != nil {
+		return err
+	}
+
+	return fd.Sync()
+}
@@ -0,0 +1,39 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"context"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/inet"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/minio/minio-go/v7"
+	"io"
+)
+
+// parts of a multipart upload are buffered in memory. S3 allows 10000 parts, capping a dump at ~160GB
+const s3PartSize = 16 * 1024 * 1024
+
+// StreamS3 multipart-uploads the dump into the bucket. Parts are invisible until the upload completes, so a failed
+// stream never shows up as a backup. Should the dump process still fail after the upload completed, the dump is removed
+func StreamS3(byteBuffer *io.ReadCloser, filename string, ex *exec.Exec, s3 *inet.S3) error {
+
+	// an unknown size always takes the multipart route, which is aborted if the stream fails
+	_, err := s3.GetClient().PutObject(context.Background(), s3.Bucket(), s3.Key(filename), *byteBuffer, -1, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+		PartSize:    s3PartSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = ex.Wait(); err != nil {
+		if rmErr := s3.Remove(filename); rmErr != nil {
+			logging.Error(rmErr, logging.Fields{})
+		}
+		return err
+	}
+
+	return nil
+}
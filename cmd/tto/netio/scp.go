@@ -11,104 +11,80 @@ import (
 	"fmt"
 	"github.com/ctomkow/tto/cmd/tto/exec"
 	"github.com/ctomkow/tto/cmd/tto/inet"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/logging"
 	"io"
+	"io/ioutil"
+	"os"
 	"path"
+	"strings"
 	"sync"
 	"time"
 )
 
+// StreamMySqlDump copies the dump over scp. scp announces the size of a file before its contents, so the dump is
+// spooled to a temporary file on the sender first. The dump arrives byte for byte as it was checksummed
 func StreamMySqlDump(byteBuffer *io.ReadCloser, filename string, workingDir string, permissions string, ex *exec.Exec, sh *inet.SSH) error {
 
-	// ensure a new session is created before acting!
-	if err := sh.NewSession(); err != nil {
+	spool, err := ioutil.TempFile("", "tto-*.part")
+	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = spool.Close()
+		if err := os.Remove(spool.Name()); err != nil {
+			logging.Error(err, logging.Fields{Event: logging.Transfer})
+		}
+	}()
 
-	// add dashes (comment delimiter) to end of db dump to flush scp BUF because we are not specifying the exact file size
-	//    https://salsa.debian.org/ssh-team/openssh/blob/master/scp.c
-	//    https://github.com/openssh/openssh-portable/blob/master/scp.c
-	//
-	//    #define COPY_BUFLEN	16384
-	//
-	//    since this is a hack to make scp do our bidding, increase current COPY_BUFLEN by an order of magnitude
-	//    this only adds 160kB of overhead, not an issue when most prod databases are hundreds of MB or GB's.
-	//
-	//    the buffer is a valid SQL comment
-	//    https://docs.oracle.com/cd/B12037_01/server.101/b10759/sql_elements006.htm
-	//    -- zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz[...]\n
-	COPY_BUFLEN := 16384 * 10
-	buf := make([]byte, COPY_BUFLEN)
-	buf[0] = '-'
-	buf[1] = '-'
-	buf[2] = ' '
-	for i := 3; i < COPY_BUFLEN-1; i++ {
-		buf[i] = 'z'
+	size, err := io.Copy(spool, *byteBuffer)
+	if err != nil {
+		return err
+	}
+	if err = ex.Wait(); err != nil {
+		return err
+	}
+	if _, err = spool.Seek(0, io.SeekStart); err != nil {
+		return err
 	}
-	buf[COPY_BUFLEN-1] = '\n'
-	flush := bytes.NewReader(buf)
 
-	// since i don't know the size of the dump, set a static max to 100GB (107374182400 bytes)
-	if err := stream(*byteBuffer, workingDir+filename, permissions, 107374182400, ex, sh, flush); err != nil {
-		glog.Error(err)
+	return UploadScp(spool, size, filename, workingDir, permissions, sh)
+}
+
+// UploadScp copies a file of a known size, e.g. a binary log, over scp
+func UploadScp(r io.Reader, size int64, filename string, workingDir string, permissions string, sh *inet.SSH) error {
+
+	// ensure a new session is created before acting!
+	if err := sh.NewSession(); err != nil {
+		return err
 	}
 
-	return nil
+	return stream(r, workingDir+filename, permissions, size, sh)
 }
 
-func stream(r io.ReadCloser, absolutePath string, permissions string, size int64, ex *exec.Exec, sh *inet.SSH, flush io.Reader) error {
+// stream copies r over scp, the remote scp must take all of it and exit cleanly
+func stream(r io.Reader, absolutePath string, permissions string, size int64, sh *inet.SSH) error {
 
 	filename := path.Base(absolutePath)
 	directory := path.Dir(absolutePath)
 
+	w, err := sh.Session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	sh.Session.Stderr = &stderr
+
 	wg := sync.WaitGroup{}
 	wg.Add(2)
 
-	errCh := make(chan error, 2)
-
+	var sendErr, runErr error
 	go func() {
 		defer wg.Done()
-		w, err := sh.Session.StdinPipe()
-		if err != nil {
-			errCh <- err
-			return
-		}
-		defer func() {
-			if err := w.Close(); err != nil {
-				glog.Exit(err)
-			}
-		}()
-		_, err = fmt.Fprintln(w, "C"+permissions, size, filename)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		_, err = io.Copy(w, r)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		_, err = io.Copy(w, flush)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		_, err = fmt.Fprint(w, "\x00")
-		if err != nil {
-			errCh <- err
-			return
-		}
+		sendErr = send(w, r, filename, permissions, size)
 	}()
-
 	go func() {
 		defer wg.Done()
-		err := sh.Session.Run(fmt.Sprintf("%s -qt %s", "/usr/bin/scp", directory))
-		if err != nil {
-			// The SCP process is existing with code 1 because the session is being forcefully closed after transfer is complete
-			//   SCP would properly close if we specify a correct file size, but we don't know that because we are streaming mysqldump
-			//   Therefore it is set to a max of 100GB
-			//   Consequently, we cannot handle an error case here :/
-		}
+		runErr = sh.Session.Run(fmt.Sprintf("%s -qt %s", "/usr/bin/scp", directory))
 	}()
 
 	// time.Duration is in nanoseconds. Default is 1000 seconds
@@ -116,18 +92,32 @@ func stream(r io.ReadCloser, absolutePath string, permissions string, size int64
 		return errors.New("timeout when upload files")
 	}
 
-	if err := ex.Cmd.Wait(); err != nil {
-		return err
+	// scp's exit status says whether the file arrived, a failed send is only a symptom
+	if runErr != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(runErr.Error() + ": " + msg)
+		}
+		return runErr
 	}
 
-	close(errCh)
-	for err := range errCh {
-		if err != nil {
-			return err
-		}
+	return sendErr
+}
+
+// send writes a single file of size bytes in the scp protocol and closes w, so scp exits once it's stored
+func send(w io.WriteCloser, r io.Reader, filename string, permissions string, size int64) error {
+
+	_, err := fmt.Fprintln(w, "C"+permissions, size, filename)
+	if err == nil {
+		_, err = io.CopyN(w, r, size)
+	}
+	if err == nil {
+		_, err = fmt.Fprint(w, "\x00")
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
 	}
 
-	return nil
+	return err
 }
 
 func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
@@ -0,0 +1,117 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/inet"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/pkg/sftp"
+	"io"
+	"os"
+)
+
+// StreamSftp streams the dump over the sftp subsystem of the existing ssh connection.
+// The dump is written to filename as given and synced to disk once the dump process has exited successfully. On any
+// failure the file is removed. Renaming it into place is left to the caller, see sshDestination
+func StreamSftp(byteBuffer *io.ReadCloser, filename string, workingDir string, permissions os.FileMode, ex *exec.Exec, sh *inet.SSH) error {
+
+	client, err := sftp.NewClient(sh.GetClient())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	return create(client, *byteBuffer, workingDir+filename, permissions, ex.Wait)
+}
+
+// UploadSftp uploads a file that isn't the output of a dump process, e.g. a binary log. It is written to a temporary
+// name and atomically renamed into place, so a partial upload never shows up
+func UploadSftp(r io.Reader, filename string, workingDir string, permissions os.FileMode, sh *inet.SSH) error {
+
+	client, err := sftp.NewClient(sh.GetClient())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			logging.Error(err, logging.Fields{})
+		}
+	}()
+
+	return upload(client, r, workingDir+filename, permissions, func() error { return nil })
+}
+
+// upload copies r into a temporary file next to absolutePath and renames it into place.
+// done is called after the copy and must return nil for the upload to be committed
+func upload(client *sftp.Client, r io.Reader, absolutePath string, permissions os.FileMode, done func() error) error {
+
+	tmpPath := absolutePath + ".part"
+
+	if err := create(client, r, tmpPath, permissions, done); err != nil {
+		return err
+	}
+
+	if err := client.PosixRename(tmpPath, absolutePath); err != nil {
+		_ = client.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// create copies r into the file at path. done is called after the copy and must return nil, otherwise the file is removed
+func create(client *sftp.Client, r io.Reader, path string, permissions os.FileMode, done func() error) error {
+
+	fd, err := client.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+
+	if err = write(fd, r, permissions, done); err != nil {
+		_ = fd.Close()
+		if rmErr := client.Remove(path); rmErr != nil {
+			logging.Error(rmErr, logging.Fields{})
+		}
+		return err
+	}
+
+	if err = fd.Close(); err != nil {
+		_ = client.Remove(path)
+		return err
+	}
+
+	return nil
+}
+
+func write(fd *sftp.File, r io.Reader, permissions os.FileMode, done func() error) error {
+
+	if err := fd.Chmod(permissions); err != nil {
+		return err
+	}
+
+	if _, err := fd.ReadFrom(r); err != nil {
+		return err
+	}
+
+	if err := done(); err != nil {
+		return err
+	}
+
+	// fsync is an openssh extension, not every server has it
+	if err := fd.Sync(); err != nil {
+		var statusErr *sftp.StatusError
+		if !errors.As(err, &statusErr) || statusErr.FxCode() != sftp.ErrSSHFxOpUnsupported {
+			return err
+		}
+		logging.Warning("remote sftp server does not support fsync, skipping", logging.Fields{Event: logging.Transfer})
+	}
+
+	return nil
+}
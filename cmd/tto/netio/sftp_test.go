@@ -0,0 +1,111 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"errors"
+	"github.com/pkg/sftp"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// in-process sftp server on the local filesystem, connected to a client through pipes
+func newTestSftpClient(t *testing.T) *sftp.Client {
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	server, err := sftp.NewServer(pipeConn{serverReader, serverWriter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve() }()
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestUpload(t *testing.T) {
+
+	client := newTestSftpClient(t)
+	dumpPath := filepath.Join(t.TempDir(), "databaseName_-_20191018120000.sql")
+	dump := strings.Repeat("INSERT INTO t VALUES (1);\n", 10000)
+
+	err := upload(client, strings.NewReader(dump), dumpPath, 0600, func() error { return nil })
+	if err != nil {
+		t.Fatalf("Sftp upload test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	contents, err := ioutil.ReadFile(dumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != dump {
+		t.Errorf("Sftp upload test failed; found, expected: %d, %d bytes", len(contents), len(dump))
+	}
+
+	info, err := os.Stat(dumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Sftp upload test failed; found, expected: %o, %o", info.Mode().Perm(), 0600)
+	}
+	if _, err = os.Stat(dumpPath + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Sftp upload test failed; found, expected: %#v, %s", err, "temp file removed")
+	}
+}
+
+func TestUpload_DumpFailed(t *testing.T) {
+
+	client := newTestSftpClient(t)
+	dumpPath := filepath.Join(t.TempDir(), "databaseName_-_20191018120000.sql")
+
+	err := upload(client, strings.NewReader("-- partial"), dumpPath, 0600, func() error { return errors.New("exit status 2") })
+	if err == nil {
+		t.Errorf("Sftp upload dump failed test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+
+	// neither the dump nor the temporary file may be left behind
+	if _, err = os.Stat(dumpPath); !os.IsNotExist(err) {
+		t.Errorf("Sftp upload dump failed test failed; found, expected: %#v, %s", err, "no dump file")
+	}
+	if _, err = os.Stat(dumpPath + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Sftp upload dump failed test failed; found, expected: %#v, %s", err, "no temp file")
+	}
+}
+
+func TestCreate(t *testing.T) {
+
+	client := newTestSftpClient(t)
+	partPath := filepath.Join(t.TempDir(), "databaseName_-_20191018120000.sql.part")
+
+	// the file is written under the name it's given, the caller renames it into place
+	err := create(client, strings.NewReader("-- dump"), partPath, 0600, func() error { return nil })
+	if err != nil {
+		t.Fatalf("Sftp create test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if _, err = os.Stat(partPath); err != nil {
+		t.Errorf("Sftp create test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if _, err = os.Stat(partPath + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Sftp create test failed; found, expected: %#v, %s", err, "no doubled .part file")
+	}
+}
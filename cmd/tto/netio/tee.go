@@ -0,0 +1,67 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"errors"
+	"io"
+)
+
+// Tee streams r to n readers at once, so a dump is only produced once for all destinations.
+// Readers are fed in lockstep, each must be read to the end or closed. A closed reader stops receiving the stream.
+// With requireAll, one closed reader fails the stream of all the others. Otherwise the stream goes on as long as
+// a reader is left. Once every reader is gone r is abandoned, the caller has to stop whatever produces it
+func Tee(r io.Reader, n int, requireAll bool) []io.ReadCloser {
+
+	readers := make([]io.ReadCloser, n)
+	writers := make([]*io.PipeWriter, n)
+	for i := range readers {
+		readers[i], writers[i] = io.Pipe()
+	}
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		live := n
+
+		for live > 0 {
+			nr, err := r.Read(buf)
+			if nr > 0 {
+				for i, w := range writers {
+					if w == nil {
+						continue
+					}
+					if _, werr := w.Write(buf[:nr]); werr != nil {
+						writers[i] = nil
+						live--
+						if requireAll {
+							closeWriters(writers, errors.New("stream aborted, another destination failed"))
+							return
+						}
+					}
+				}
+			}
+			if err == io.EOF {
+				closeWriters(writers, nil)
+				return
+			}
+			if err != nil {
+				closeWriters(writers, err)
+				return
+			}
+		}
+	}()
+
+	return readers
+}
+
+// close the writers still in use, with err surfacing on their readers
+func closeWriters(writers []*io.PipeWriter, err error) {
+	for i, w := range writers {
+		if w == nil {
+			continue
+		}
+		_ = w.CloseWithError(err)
+		writers[i] = nil
+	}
+}
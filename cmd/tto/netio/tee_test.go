@@ -0,0 +1,68 @@
+// Craig Tomkow
+// October 18, 2026
+
+package netio
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTee(t *testing.T) {
+
+	dump := strings.Repeat("INSERT INTO t VALUES (1,'teed');\n", 5000)
+	readers := Tee(strings.NewReader(dump), 3, true)
+
+	results := make([]string, len(readers))
+	wg := sync.WaitGroup{}
+	for i, reader := range readers {
+		wg.Add(1)
+		go func(i int, reader io.Reader) {
+			defer wg.Done()
+			contents, err := ioutil.ReadAll(reader)
+			if err != nil {
+				t.Errorf("Tee test failed; found, expected: %#v, %s", err, "nil err")
+			}
+			results[i] = string(contents)
+		}(i, reader)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if result != dump {
+			t.Errorf("Tee test failed; reader %d found, expected: %d, %d bytes", i, len(result), len(dump))
+		}
+	}
+}
+
+func TestTee_ClosedReader(t *testing.T) {
+
+	dump := strings.Repeat("INSERT INTO t VALUES (1,'teed');\n", 5000)
+
+	for _, requireAll := range []bool{false, true} {
+
+		readers := Tee(strings.NewReader(dump), 2, requireAll)
+
+		// the first destination fails right away
+		if err := readers[0].Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		contents, err := ioutil.ReadAll(readers[1])
+		if requireAll {
+			if err == nil {
+				t.Errorf("Tee closed reader test failed; found, expected: %#v, %s", err, "not nil err")
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Tee closed reader test failed; found, expected: %#v, %s", err, "nil err")
+		}
+		if string(contents) != dump {
+			t.Errorf("Tee closed reader test failed; found, expected: %d, %d bytes", len(contents), len(dump))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Craig Tomkow
+// October 18, 2026
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"strconv"
+	"strings"
+)
+
+// a local command, the event is passed as JSON on stdin and in TTO_* environment variables. It is killed when it runs
+// past the timeout
+type command struct {
+	command []string
+}
+
+func NewCommand(cmd []string) Notifier {
+	return &command{command: cmd}
+}
+
+func (c *command) Notify(event Event) error {
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	env := []string{
+		"TTO_EVENT=" + event.Event,
+		"TTO_SUCCESS=" + strconv.FormatBool(event.Success),
+		"TTO_DB=" + event.DB,
+		"TTO_DESTINATION=" + event.Destination,
+		"TTO_DUMP=" + event.Dump,
+		"TTO_ERROR=" + event.Error,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return new(exec.Exec).LocalCmdStdinContext(ctx, c.command, env, bytes.NewReader(payload))
+}
+
+func (c *command) String() string {
+	return strings.Join(c.command, " ")
+}
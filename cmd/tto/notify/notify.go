@@ -0,0 +1,143 @@
+// Craig Tomkow
+// October 18, 2026
+
+// Package notify sends the outcome of dumps, transfers, deletes and restores to the configured notifiers,
+// e.g. a webhook, an email or a command
+package notify
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"os"
+	"sync"
+	"time"
+)
+
+// the events notified about
+const (
+	Dump     = "dump"
+	Transfer = "transfer"
+	Delete   = "delete"
+	Restore  = "restore"
+)
+
+// a notifier gets this long to deliver an event, events are sent in line with the backups and restores
+var timeout = 10 * time.Second
+
+// Event is the outcome of a dump, transfer, delete or restore
+type Event struct {
+	Event       string    `json:"event"`
+	Success     bool      `json:"success"`
+	DB          string    `json:"db"`
+	Destination string    `json:"destination,omitempty"`
+	Dump        string    `json:"dump,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Host        string    `json:"host"`
+	Time        time.Time `json:"time"`
+}
+
+// Notifier delivers events
+type Notifier interface {
+	Notify(event Event) error
+	String() string
+}
+
+// a notifier with the events and outcomes it is sent
+type subscription struct {
+	notifier Notifier
+	events   map[string]bool
+	on       string
+}
+
+var (
+	mu            sync.Mutex
+	subscriptions []subscription
+)
+
+// Register sends the notifier the given events, all of them if empty. on is "failure" (default), "success" or "always"
+func Register(notifier Notifier, events []string, on string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sub := subscription{notifier: notifier, on: on}
+	if len(events) != 0 {
+		sub.events = make(map[string]bool)
+		for _, event := range events {
+			sub.events[event] = true
+		}
+	}
+	subscriptions = append(subscriptions, sub)
+}
+
+// Reset removes all notifiers
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	subscriptions = nil
+}
+
+func (sub subscription) wants(event Event) bool {
+
+	if sub.events != nil && !sub.events[event.Event] {
+		return false
+	}
+	switch sub.on {
+	case "always":
+		return true
+	case "success":
+		return event.Success
+	default:
+		return !event.Success
+	}
+}
+
+// Success notifies a successful event. destination and dump may be empty
+func Success(event string, db string, destination string, dump string) {
+	Send(Event{Event: event, Success: true, DB: db, Destination: destination, Dump: dump})
+}
+
+// Failure notifies a failed event. destination and dump may be empty
+func Failure(event string, db string, destination string, dump string, err error) {
+	Send(Event{Event: event, DB: db, Destination: destination, Dump: dump, Error: err.Error()})
+}
+
+// Send delivers the event to the notifiers that want it. A notifier that fails is logged, it doesn't fail the event
+func Send(event Event) {
+
+	mu.Lock()
+	subs := append([]subscription(nil), subscriptions...)
+	mu.Unlock()
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.Host == "" {
+		event.Host, _ = os.Hostname()
+	}
+
+	for _, sub := range subs {
+		if !sub.wants(event) {
+			continue
+		}
+		if err := sub.notifier.Notify(event); err != nil {
+			logging.Error(errors.New("failed to notify "+sub.notifier.String()+" of "+event.Event+": "+err.Error()), logging.Fields{Event: event.Event, DB: event.DB, Dump: event.Dump, Destination: event.Destination})
+		}
+	}
+}
+
+// Subject summarizes the event in a line, e.g. for an email subject
+func (event Event) Subject() string {
+
+	outcome := "succeeded"
+	if !event.Success {
+		outcome = "failed"
+	}
+	subject := "tto: " + event.Event + " of " + event.DB
+	if event.Destination != "" && event.Event == Transfer {
+		subject += " to " + event.Destination
+	} else if event.Destination != "" {
+		subject += " at " + event.Destination
+	}
+	return subject + " " + outcome
+}
@@ -0,0 +1,239 @@
+// Craig Tomkow
+// October 18, 2026
+
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a notifier that remembers the events it was sent
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Notify(event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) String() string {
+	return "recorder"
+}
+
+func TestSend(t *testing.T) {
+
+	defer Reset()
+
+	failures, successes, restores := new(recorder), new(recorder), new(recorder)
+	Register(failures, nil, "")
+	Register(successes, nil, "success")
+	Register(restores, []string{Restore}, "always")
+
+	Success(Dump, "databaseName", "", "databaseName_-_20190802120000.sql")
+	Failure(Transfer, "databaseName", "6.6.6.6", "databaseName_-_20190802120000.sql", errors.New("connection lost"))
+	Success(Restore, "databaseName", "", "databaseName_-_20190802120000.sql")
+	Failure(Restore, "databaseName", "", "databaseName_-_20190802120000.sql", errors.New("checksum mismatch"))
+
+	if len(failures.events) != 2 || failures.events[0].Event != Transfer || failures.events[0].Error != "connection lost" {
+		t.Errorf("Notify failure test failed; found, expected: %v, %d transfer and restore failures", failures.events, 2)
+	}
+	if len(successes.events) != 2 || successes.events[0].Event != Dump || successes.events[1].Event != Restore {
+		t.Errorf("Notify success test failed; found, expected: %v, %d dump and restore successes", successes.events, 2)
+	}
+	if len(restores.events) != 2 || restores.events[0].Event != Restore || restores.events[1].Event != Restore {
+		t.Errorf("Notify events test failed; found, expected: %v, %d restores", restores.events, 2)
+	}
+	if event := failures.events[0]; event.Host == "" || event.Time.IsZero() {
+		t.Errorf("Notify event test failed; found, expected: %v, a host and time", event)
+	}
+}
+
+func TestSubject(t *testing.T) {
+
+	tests := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Event: Dump, Success: true, DB: "databaseName"}, "tto: dump of databaseName succeeded"},
+		{Event{Event: Transfer, DB: "databaseName", Destination: "6.6.6.6"}, "tto: transfer of databaseName to 6.6.6.6 failed"},
+		{Event{Event: Delete, Success: true, DB: "databaseName", Destination: "6.6.6.6"}, "tto: delete of databaseName at 6.6.6.6 succeeded"},
+	}
+
+	for _, test := range tests {
+		if found := test.event.Subject(); found != test.expected {
+			t.Errorf("Event subject test failed; found, expected: %s, %s", found, test.expected)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+
+	var received Event
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	wh := NewWebhook(server.URL)
+	event := Event{Event: Transfer, DB: "databaseName", Destination: "6.6.6.6", Error: "connection lost"}
+	if err := wh.Notify(event); err != nil {
+		t.Fatal(err)
+	}
+	if received.Event != Transfer || received.Success || received.Destination != "6.6.6.6" || received.Error != "connection lost" {
+		t.Errorf("Webhook payload test failed; found, expected: %v, %v", received, event)
+	}
+
+	status = http.StatusInternalServerError
+	if err := wh.Notify(event); err == nil {
+		t.Errorf("Webhook status test failed; found, expected: %v, an error", err)
+	}
+}
+
+// fakeSMTP accepts a single email and returns its message on the channel
+func fakeSMTP(t *testing.T) (string, chan string) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := make(chan string, 1)
+
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch verb := strings.ToUpper(strings.Fields(line)[0]); verb {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for !strings.HasSuffix(data.String(), "\r\n.\r\n") {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					data.WriteString(line)
+				}
+				message <- data.String()
+				reply("250 ok")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), message
+}
+
+func TestSMTP(t *testing.T) {
+
+	addr, message := fakeSMTP(t)
+
+	m := NewSMTP(addr, "", "", "tto@example.com", []string{"dba@example.com"})
+	event := Event{Event: Restore, DB: "databaseName", Dump: "databaseName_-_20190802120000.sql", Error: "checksum mismatch"}
+	if err := m.Notify(event); err != nil {
+		t.Fatal(err)
+	}
+
+	found := <-message
+	for _, expected := range []string{"Subject: tto: restore of databaseName failed\r\n", "To: dba@example.com\r\n", "error: checksum mismatch\r\n"} {
+		if !strings.Contains(found, expected) {
+			t.Errorf("SMTP message test failed; found, expected: %q, %q", found, expected)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "event")
+
+	c := NewCommand([]string{"sh", "-c", `cat > "$0"; echo >> "$0"; echo "$TTO_EVENT $TTO_SUCCESS $TTO_DB" >> "$0"`, out})
+	if err := c.Notify(Event{Event: Dump, Success: true, DB: "databaseName"}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(found)), "\n")
+	var event Event
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[0]), &event) != nil || event.DB != "databaseName" || lines[1] != "dump true databaseName" {
+		t.Errorf("Command notify test failed; found, expected: %q, the event on stdin and in the environment", found)
+	}
+
+	if err := NewCommand([]string{"false"}).Notify(Event{Event: Dump}); err == nil {
+		t.Errorf("Command exit status test failed; found, expected: %v, an error", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+
+	defer func(original time.Duration) { timeout = original }(timeout)
+	timeout = 100 * time.Millisecond
+
+	// an SMTP server that accepts but never greets
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	notifiers := []Notifier{
+		NewSMTP(ln.Addr().String(), "", "", "tto@example.com", []string{"dba@example.com"}),
+		NewCommand([]string{"sleep", "5"}),
+	}
+	for _, notifier := range notifiers {
+		start := time.Now()
+		if err := notifier.Notify(Event{Event: Dump, DB: "databaseName"}); err == nil {
+			t.Errorf("Notify timeout test failed; found, expected: %v, %s", err, "a timeout err")
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Notify timeout test failed; found, expected: %v, %s for %s", elapsed, "within the timeout", notifier)
+		}
+	}
+}
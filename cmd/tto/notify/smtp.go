@@ -0,0 +1,109 @@
+// Craig Tomkow
+// October 18, 2026
+
+package notify
+
+import (
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// an email, sent through an SMTP server. Without a username, the server is used without authentication
+type mail struct {
+	server   string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func NewSMTP(server string, username string, password string, from string, to []string) Notifier {
+	return &mail{server: server, username: username, password: password, from: from, to: to}
+}
+
+// send the email like smtp.SendMail does, but within the timeout
+func (m *mail) Notify(event Event) error {
+
+	host, _, err := net.SplitHostPort(m.server)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", m.server, timeout)
+	if err != nil {
+		return err
+	}
+	if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", m.username, m.password, host)); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(m.from); err != nil {
+		return err
+	}
+	for _, to := range m.to {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(m.message(event)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (m *mail) message(event Event) []byte {
+
+	body := []string{
+		"event: " + event.Event,
+		"db: " + event.DB,
+	}
+	if event.Destination != "" {
+		body = append(body, "destination: "+event.Destination)
+	}
+	if event.Dump != "" {
+		body = append(body, "dump: "+event.Dump)
+	}
+	if event.Error != "" {
+		body = append(body, "error: "+event.Error)
+	}
+	body = append(body, "host: "+event.Host, "time: "+event.Time.Format(time.RFC3339))
+
+	headers := []string{
+		"From: " + m.from,
+		"To: " + strings.Join(m.to, ", "),
+		"Subject: " + event.Subject(),
+		"Date: " + event.Time.Format(time.RFC1123Z),
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.Join(body, "\r\n") + "\r\n")
+}
+
+func (m *mail) String() string {
+	return "smtp://" + m.server
+}
@@ -0,0 +1,45 @@
+// Craig Tomkow
+// October 18, 2026
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// a generic webhook, the event is POSTed to it as JSON
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string) Notifier {
+	return &webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (wh *webhook) Notify(event Event) error {
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := wh.client.Post(wh.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("webhook responded " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+func (wh *webhook) String() string {
+	return wh.url
+}
@@ -0,0 +1,102 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"errors"
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"github.com/ctomkow/tto/cmd/tto/db"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"strings"
+	"time"
+)
+
+// restoreTo restores the database to how it was at target, a timestamp in UTC like the dump names: the newest dump
+// taken before target, then the changes since from the shipped binary logs, up to target.
+// dbName picks the database when the receiver restores several
+func restoreTo(conf *conf.Config, target string, dbName string) (string, error) {
+
+	if conf.System.Type != "receiver" {
+		return "", errors.New("restore-to is only available on the receiver")
+	}
+
+	stop, err := parseTimeString(target)
+	if err != nil {
+		return "", err
+	}
+	job, err := receiverJob(conf.ReceiverJobs(), dbName)
+	if err != nil {
+		return "", err
+	}
+	dumps, err := sortedDumps(conf.System.WorkingDir, job.DBname)
+	if err != nil {
+		return "", err
+	}
+	dumpName, err := newestDumpBefore(dumps, stop)
+	if err != nil {
+		return "", err
+	}
+	if job.Database != "mysql" {
+		return "", errors.New("point-in-time recovery needs a mysql database")
+	}
+
+	// the dump is only restored once the binary logs can take it the rest of the way, all of them decoded
+	chain, err := backup.ResolveBinlogs(conf.System.WorkingDir, job.DBname, dumpName, conf.System.Role.Receiver.IdentityFile)
+	if err != nil {
+		return "", err
+	}
+	defer chain.Remove()
+
+	err = runRestore(conf, job, func(dB db.DB, exe *exec.Exec) error {
+		binlogs, ok := dB.(db.Binlogs)
+		if !ok {
+			return errors.New("point-in-time recovery needs a mysql database")
+		}
+		if err := backup.RestoreDump(dB, conf.System.WorkingDir, dumpName, conf.System.Role.Receiver.IdentityFile, conf.System.Role.Receiver.RestoreMode, conf.System.Role.Receiver.RestoreEngine, conf.System.Role.Receiver.AllowMissingChecksum, exe); err != nil {
+			return err
+		}
+		return backup.ReplayBinlogs(binlogs, chain, stop, exe)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return dumpName, nil
+}
+
+// receiverJob returns the job restoring into dbName. Without dbName, the receiver must restore a single database
+func receiverJob(jobs []conf.ReceiverJob, dbName string) (conf.ReceiverJob, error) {
+
+	if dbName == "" {
+		if len(jobs) != 1 {
+			return conf.ReceiverJob{}, errors.New("the receiver restores several databases, name the one to restore")
+		}
+		return jobs[0], nil
+	}
+
+	var names []string
+	for _, job := range jobs {
+		if job.DBname == dbName {
+			return job, nil
+		}
+		names = append(names, job.DBname)
+	}
+	return conf.ReceiverJob{}, errors.New("database " + dbName + " is not restored by the receiver, found: " + strings.Join(names, ", "))
+}
+
+// newestDumpBefore returns the newest of the sorted dumps taken at or before target
+func newestDumpBefore(dumps []backup.Dump, target time.Time) (string, error) {
+
+	for i := len(dumps) - 1; i >= 0; i-- {
+		taken, err := parseBackupTimestamp(dumps[i].Name)
+		if err != nil {
+			return "", err
+		}
+		if !taken.After(target) {
+			return dumps[i].Name, nil
+		}
+	}
+	return "", errors.New("no dump found taken before " + target.Format("2006-01-02 15:04:05") + " UTC")
+}
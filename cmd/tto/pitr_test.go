@@ -0,0 +1,95 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewestDumpBefore(t *testing.T) {
+
+	dumps := []backup.Dump{
+		{Name: "databaseName_-_20190802120000.sql.gz"},
+		{Name: "databaseName_-_20190803120000.sql.gz"},
+	}
+
+	var tests = []struct {
+		target   time.Time
+		expected string
+	}{
+		{time.Date(2019, 8, 2, 12, 0, 0, 0, time.UTC), "databaseName_-_20190802120000.sql.gz"},
+		{time.Date(2019, 8, 3, 11, 59, 59, 0, time.UTC), "databaseName_-_20190802120000.sql.gz"},
+		{time.Date(2019, 8, 4, 0, 0, 0, 0, time.UTC), "databaseName_-_20190803120000.sql.gz"},
+		{time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC), ""},
+	}
+
+	for _, test := range tests {
+		found, err := newestDumpBefore(dumps, test.target)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("Newest dump before test failed; found, expected: %s, %s", found, "err")
+			}
+			continue
+		}
+		if err != nil || found != test.expected {
+			t.Errorf("Newest dump before test failed; found, expected: %s %#v, %s", found, err, test.expected)
+		}
+	}
+}
+
+func TestReceiverJob(t *testing.T) {
+
+	single := []conf.ReceiverJob{{DBname: "databaseName"}}
+	several := []conf.ReceiverJob{{DBname: "databaseName"}, {DBname: "otherName"}}
+
+	if job, err := receiverJob(single, ""); err != nil || job.DBname != "databaseName" {
+		t.Errorf("Receiver job test failed; found, expected: %+v %#v, %s", job, err, "the only job")
+	}
+	if _, err := receiverJob(several, ""); err == nil {
+		t.Errorf("Receiver job test failed; found, expected: %#v, %s", err, "ambiguous err")
+	}
+	if job, err := receiverJob(several, "otherName"); err != nil || job.DBname != "otherName" {
+		t.Errorf("Receiver job test failed; found, expected: %+v %#v, %s", job, err, "the named job")
+	}
+	if _, err := receiverJob(several, "unknown"); err == nil {
+		t.Errorf("Receiver job test failed; found, expected: %#v, %s", err, "unknown database err")
+	}
+}
+
+func TestRestoreTo_MissingBinlog(t *testing.T) {
+
+	dir := t.TempDir() + "/"
+	dump := "-- CHANGE MASTER TO MASTER_LOG_FILE='binlog.000042', MASTER_LOG_POS=157;\nCREATE TABLE t (id int);\n"
+	if err := ioutil.WriteFile(dir+"databaseName_-_20190802120000.sql", []byte(dump), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(dir+"databaseName_-_binlog_-_binlog.000043", []byte("later"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := new(conf.Config)
+	config.System.Type = "receiver"
+	config.System.WorkingDir = dir
+	config.System.Role.Receiver.Jobs = []conf.ReceiverJob{{
+		DBname:     "databaseName",
+		Database:   "mysql",
+		ExecBefore: []string{"touch", dir + "exec_before"},
+		ExecAfter:  []string{"true"},
+	}}
+
+	// the binary log the dump was taken in is missing, nothing is restored
+	_, err := restoreTo(config, "20190802130000", "")
+	if err == nil || !strings.Contains(err.Error(), "binlog.000042 has not been shipped") {
+		t.Errorf("Restore to test failed; found, expected: %#v, %s", err, "not shipped err")
+	}
+	if _, err = os.Stat(dir + "exec_before"); !os.IsNotExist(err) {
+		t.Errorf("Restore to test failed; found, expected: %#v, %s", err, "exec_before never ran")
+	}
+}
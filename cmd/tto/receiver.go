@@ -8,63 +8,89 @@ import (
 	"github.com/ctomkow/tto/cmd/tto/backup"
 	"github.com/ctomkow/tto/cmd/tto/conf"
 	"github.com/ctomkow/tto/cmd/tto/db"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/metrics"
+	"github.com/ctomkow/tto/cmd/tto/notify"
 	"github.com/fsnotify/fsnotify"
-	"github.com/golang/glog"
 	"net"
 	"os"
+	"path/filepath"
 	"time"
 )
 
 type lock struct {
 	restore bool
+
+	// releases the restore lock file, shared with on-demand restores
+	release func()
+}
+
+// a restore job of the receiver, each with its own database, restore lock and exec handler
+type restoreJob struct {
+	conf conf.ReceiverJob
+	dB   db.DB
+	lck  *lock
+	exe  *exec.Exec
+}
+
+// the outcome of a restore, restoredDump is empty when it failed
+type restoreResult struct {
+	j            *restoreJob
+	restoredDump string
 }
 
 func Receiver(conf *conf.Config) error {
 
 	// setup various components
 	//   - signal interrupts
-	//   - local database
+	//   - a job per database, each with
+	//     - local database
+	//     - restore lock
+	//     - os exec process handling
 	//   - file watcher
-	//   - restore lock
-	//   - restore channel for the restore database routine
-	//   - os exec process handling
+	//   - restore channel for the restore database routines
 
 	interrupt := newSignal()
-	dB := newReceiverDb(
-		conf.System.Role.Receiver.Database,
-		conf.System.Role.Receiver.DBip,
-		conf.System.Role.Receiver.DBport,
-		conf.System.Role.Receiver.DBuser,
-		conf.System.Role.Receiver.DBpass,
-		conf.System.Role.Receiver.DBname,
-		10,
-	)
+	jobs, err := newRestoreJobs(conf)
+	if err != nil {
+		return err
+	}
 	watcher, err := newFileWatcher()
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if err := watcher.Close(); err != nil {
-			glog.Exit(err)
+			logging.Error(err, logging.Fields{})
 		}
 	}()
-	var lck = new(lock)
-	restoreChan := make(chan string)
-	exe := newExecHandler()
+	restoreChan := make(chan restoreResult)
 
 	// create working components
-	//   - open database connection
-	//   - watch file for changes
+	//   - open database connections
+	//   - watch the .latest.dump file of each database for changes
 	//   - file change event variable
 
-	// useful for when the tto server boots up faster than the database server
-	if err := attemptDB(dB, 3, 10); err != nil {
-		return err
-	}
+	watched := make(map[string]*restoreJob)
+	for _, j := range jobs {
 
-	// FYI, VIM doesn't create a WRITE event, only RENAME, CHMOD, REMOVE (then breaks future watching). https://github.com/fsnotify/fsnotify/issues/94#issuecomment-287456396
-	if err = watcher.Add(conf.System.WorkingDir + ".latest.dump"); err != nil {
-		return err
+		// useful for when the tto server boots up faster than the database server
+		if err := attemptDB(j.dB, 3, 10); err != nil {
+			return err
+		}
+
+		// FYI, VIM doesn't create a WRITE event, only RENAME, CHMOD, REMOVE (then breaks future watching). https://github.com/fsnotify/fsnotify/issues/94#issuecomment-287456396
+		latestDump := conf.System.WorkingDir + backup.LatestDump(j.conf.DBname)
+		if err = watcher.Add(latestDump); err != nil {
+			return err
+		}
+		watched[filepath.Clean(latestDump)] = j
+
+		// the last restore survives a restart
+		if info, err := os.Stat(conf.System.WorkingDir + backup.LatestRestore(j.conf.DBname)); err == nil && info.Size() != 0 {
+			metrics.Set(metrics.RestoreLastSuccess, float64(info.ModTime().Unix()), "db", j.conf.DBname)
+		}
 	}
 	var event fsnotify.Event
 
@@ -76,54 +102,69 @@ func Receiver(conf *conf.Config) error {
 			if !isWriteEvent(event) {
 				break
 			}
-			if lck.restore {
+			j, ok := watched[filepath.Clean(event.Name)]
+			if !ok {
+				break
+			}
+			if j.lck.restore {
 				break
 			}
 
-			lck.restore = true
+			// an on-demand restore may be running
+			fields := logging.Fields{Event: logging.Restore, DB: j.conf.DBname}
+			release, err := backup.LockRestore(conf.System.WorkingDir, j.conf.DBname)
+			if err != nil {
+				logging.Error(err, fields)
+				break
+			}
+			j.lck.restore = true
+			j.lck.release = release
 
 			// run exec_before
-			output, err := exe.LocalCmd(conf.System.Role.Receiver.ExecBefore)
+			output, err := j.exe.LocalCmd(j.conf.ExecBefore)
 			if err != nil {
-				glog.Error(err)
-				lck.restore = false
+				logging.Error(err, fields)
+				notify.Failure(notify.Restore, j.conf.DBname, "", "", errors.New("exec_before: "+err.Error()))
+				j.lck.release()
+				j.lck.restore = false
 				break
 			}
-			glog.Info(errors.New(output))
+			logging.Info(output, fields)
 
-			// run restoreDatabase as a goroutine. goroutine holds a restoreDatabase lock until it's done
+			// run restoreDatabase as a goroutine. goroutine holds the job's restoreDatabase lock until it's done
 			go func() {
-				restoredDump, err := backup.Restore(dB, conf.System.WorkingDir)
+				restoredDump, err := backup.Restore(j.dB, conf.System.WorkingDir, conf.System.Role.Receiver.IdentityFile, conf.System.Role.Receiver.RestoreMode, conf.System.Role.Receiver.RestoreEngine, conf.System.Role.Receiver.AllowMissingChecksum, j.exe)
 				if err != nil {
-					glog.Error(err)
-					restoreChan <- ""
+					logging.Error(err, fields)
+					restoreChan <- restoreResult{j: j}
 					return
 				}
-				restoreChan <- restoredDump
+				restoreChan <- restoreResult{j: j, restoredDump: restoredDump}
 			}()
 
 		// trigger on dump restoreDatabase being finished
-		case restoredDump := <-restoreChan:
+		case result := <-restoreChan:
 
-			if restoredDump == "" {
-				glog.Error(errors.New("failed to restore db dump"))
-			} else {
-				glog.Info(errors.New("restored db dump: " + restoredDump))
+			// a successful restore is logged by the restore itself
+			fields := logging.Fields{Event: logging.Restore, DB: result.j.conf.DBname, Dump: result.restoredDump}
+			if result.restoredDump == "" {
+				logging.Error(errors.New("failed to restore db dump of "+result.j.conf.DBname), fields)
 			}
 
 			// run exec_after
-			output, err := exe.LocalCmd(conf.System.Role.Receiver.ExecAfter)
+			output, err := result.j.exe.LocalCmd(result.j.conf.ExecAfter)
 			if err != nil {
-				glog.Error(err)
+				logging.Error(err, fields)
 			} else {
-				glog.Info(output)
+				logging.Info(output, fields)
 			}
 
-			lck.restore = false
+			result.j.lck.release()
+			result.j.lck.restore = false
 
 		// trigger on signal
 		case killSignal := <-interrupt:
-			glog.Error(killSignal)
+			logging.Error(errors.New(killSignal.String()), logging.Fields{})
 
 			if killSignal == os.Interrupt {
 				return errors.New("daemon was interrupted by system signal")
@@ -132,8 +173,34 @@ func Receiver(conf *conf.Config) error {
 
 		}
 	}
+}
+
+// newRestoreJobs sets up a job for each database to restore into. The jobs that can't be set up are left out, their
+// errors are returned together along with the jobs that could
+func newRestoreJobs(conf *conf.Config) ([]*restoreJob, error) {
+
+	var jobs []*restoreJob
+	var errs []error
+	seen := make(map[string]bool)
+
+	for _, jobConf := range conf.ReceiverJobs() {
+
+		// dumps are tracked by database name, two jobs can't restore the same one
+		if seen[jobConf.DBname] {
+			errs = append(errs, errors.New("db "+jobConf.DBname+": restored more than once"))
+			continue
+		}
+		seen[jobConf.DBname] = true
+
+		dB := newReceiverDb(jobConf.Database, jobConf.DBip, jobConf.DBport, jobConf.DBuser, jobConf.DBpass, jobConf.DBname, 10)
+		if dB == nil {
+			errs = append(errs, errors.New("db "+jobConf.DBname+": unknown database: "+jobConf.Database))
+			continue
+		}
+		jobs = append(jobs, &restoreJob{conf: jobConf, dB: dB, lck: new(lock), exe: newExecHandler()})
+	}
 
-	return nil
+	return jobs, errors.Join(errs...)
 }
 
 func isWriteEvent(event fsnotify.Event) bool {
@@ -161,11 +228,10 @@ func newReceiverDb(impl string, ip net.IPAddr, port uint16, user string, pass st
 	case "mysql":
 		return db.NewMysql(impl, ip, port, user, pass, name, maxConn)
 	case "postgres":
-		// pass
+		return db.NewPostgres(impl, ip, port, user, pass, name, maxConn)
 	default:
 		return nil
 	}
-	return nil
 }
 
 func attemptDB(dB db.DB, tries int, delayInSec int) error {
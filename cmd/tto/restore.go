@@ -0,0 +1,198 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"github.com/ctomkow/tto/cmd/tto/db"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+)
+
+// listDumps prints the dumps of each database of the receiver, oldest first. The dump last restored is marked with *
+func listDumps(conf *conf.Config, out io.Writer) error {
+
+	if conf.System.Type != "receiver" {
+		return errors.New("list is only available on the receiver")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "DATABASE\tTIMESTAMP\tSIZE\tNAME\t"); err != nil {
+		return err
+	}
+
+	for _, job := range conf.ReceiverJobs() {
+		dumps, err := sortedDumps(conf.System.WorkingDir, job.DBname)
+		if err != nil {
+			return err
+		}
+		latestRestore := readFirstLine(conf.System.WorkingDir + backup.LatestRestore(job.DBname))
+
+		for _, dump := range dumps {
+			timestamp, err := parseBackupTimestamp(dump.Name)
+			if err != nil {
+				return err
+			}
+			marker := ""
+			if dump.Name == latestRestore {
+				marker = "*"
+			}
+			if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.DBname, timestamp.Format("2006-01-02 15:04:05"), humanSize(dump.Size), dump.Name, marker); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.Flush()
+}
+
+// restoreDump restores the dump given by name or timestamp into its database, the same way the receiver does
+func restoreDump(conf *conf.Config, target string) (string, error) {
+
+	if conf.System.Type != "receiver" {
+		return "", errors.New("restore is only available on the receiver")
+	}
+
+	job, dumpName, err := resolveDump(conf.ReceiverJobs(), conf.System.WorkingDir, target)
+	if err != nil {
+		return "", err
+	}
+
+	err = runRestore(conf, job, func(dB db.DB, exe *exec.Exec) error {
+		return backup.RestoreDump(dB, conf.System.WorkingDir, dumpName, conf.System.Role.Receiver.IdentityFile, conf.System.Role.Receiver.RestoreMode, conf.System.Role.Receiver.RestoreEngine, conf.System.Role.Receiver.AllowMissingChecksum, exe)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return dumpName, nil
+}
+
+// runRestore opens the database of the job and restores into it the same way the receiver does: exec_before,
+// the restore and exec_after, holding the restore lock of the database throughout
+func runRestore(conf *conf.Config, job conf.ReceiverJob, restore func(dB db.DB, exe *exec.Exec) error) error {
+
+	dB := newReceiverDb(job.Database, job.DBip, job.DBport, job.DBuser, job.DBpass, job.DBname, 10)
+	if dB == nil {
+		return errors.New("unknown database: " + job.Database)
+	}
+	if err := attemptDB(dB, 3, 10); err != nil {
+		return err
+	}
+
+	unlock, err := backup.LockRestore(conf.System.WorkingDir, job.DBname)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	exe := newExecHandler()
+
+	// run exec_before
+	output, err := exe.LocalCmd(job.ExecBefore)
+	if err != nil {
+		return err
+	}
+	fields := logging.Fields{Event: logging.Restore, DB: job.DBname}
+	logging.Info(output, fields)
+
+	restoreErr := restore(dB, exe)
+
+	// run exec_after, whether or not the restore succeeded
+	output, err = exe.LocalCmd(job.ExecAfter)
+	if err != nil {
+		logging.Error(err, fields)
+	} else {
+		logging.Info(output, fields)
+	}
+
+	return restoreErr
+}
+
+// resolveDump finds the job and dump matching the target, either a dump name or the timestamp of one,
+// e.g. 20190802120000. A timestamp matching dumps of several databases is ambiguous
+func resolveDump(jobs []conf.ReceiverJob, workingDir string, target string) (conf.ReceiverJob, string, error) {
+
+	var matchedJobs []conf.ReceiverJob
+	var matchedDumps []string
+
+	for _, job := range jobs {
+		dumps, err := backup.ListDumps(workingDir, job.DBname)
+		if err != nil {
+			return conf.ReceiverJob{}, "", err
+		}
+		for _, dump := range dumps {
+			if dump.Name == target || strings.HasPrefix(dump.Name, job.DBname+"_-_"+target+".") {
+				matchedJobs = append(matchedJobs, job)
+				matchedDumps = append(matchedDumps, dump.Name)
+			}
+		}
+	}
+
+	switch len(matchedDumps) {
+	case 0:
+		return conf.ReceiverJob{}, "", errors.New("no dump found matching " + target + ". See the list command")
+	case 1:
+		return matchedJobs[0], matchedDumps[0], nil
+	default:
+		return conf.ReceiverJob{}, "", errors.New(target + " matches several dumps, use the dump name: " + strings.Join(matchedDumps, ", "))
+	}
+}
+
+// sortedDumps returns the dumps of the database in the working dir, oldest first
+func sortedDumps(workingDir string, dbName string) ([]backup.Dump, error) {
+
+	dumps, err := backup.ListDumps(workingDir, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	byName := make(map[string]backup.Dump)
+	for _, dump := range dumps {
+		names = append(names, dump.Name)
+		byName[dump.Name] = dump
+	}
+
+	var sorted []backup.Dump
+	for _, name := range sortBackups(names) {
+		sorted = append(sorted, byName[name])
+	}
+	return sorted, nil
+}
+
+// readFirstLine returns the first line of the file, or nothing if it can't be read
+func readFirstLine(filename string) string {
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return strings.SplitN(string(content), "\n", 2)[0]
+}
+
+// humanSize formats a size in bytes with a binary unit, e.g. 1.5 MiB
+func humanSize(bytes int64) string {
+
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	size := float64(bytes) / unit
+	i := 0
+	for size >= unit && i < len(units)-1 {
+		size /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", size, units[i])
+}
@@ -0,0 +1,119 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"bytes"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// a receiver working dir with dumps of two databases, one of them restored, and a stray file copied in by hand
+func newWorkingDir(t *testing.T) *conf.Config {
+
+	dir := t.TempDir() + "/"
+	files := map[string]string{
+		"databaseName_-_20190802120000.sql.gz":        "first",
+		"databaseName_-_20190803120000.sql.gz":        "second dump",
+		"databaseName_-_20190803120000.sql.gz.sha256": "checksum",
+		"otherName_-_20190803120000.sql":              "other",
+		"databaseName_-_before-upgrade.sql":           "stray",
+		".latest.restore.databaseName":                "databaseName_-_20190802120000.sql.gz",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(dir+name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := new(conf.Config)
+	config.System.Type = "receiver"
+	config.System.WorkingDir = dir
+	config.System.Role.Receiver.Jobs = []conf.ReceiverJob{{DBname: "databaseName"}, {DBname: "otherName"}}
+	return config
+}
+
+func TestListDumps(t *testing.T) {
+
+	config := newWorkingDir(t)
+
+	var out bytes.Buffer
+	if err := listDumps(config, &out); err != nil {
+		t.Fatalf("List dumps test failed; found, expected: %#v, %s", err, "nil err")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("List dumps test failed; found, expected: %d, %d lines\n%s", len(lines), 4, out.String())
+	}
+	// oldest first, the restored dump marked
+	if !strings.Contains(lines[1], "databaseName_-_20190802120000.sql.gz") || !strings.HasSuffix(strings.TrimSpace(lines[1]), "*") {
+		t.Errorf("List dumps test failed; found, expected: %q, %s", lines[1], "the restored first dump")
+	}
+	if !strings.Contains(lines[2], "2019-08-03 12:00:00") || !strings.Contains(lines[2], "11 B") {
+		t.Errorf("List dumps test failed; found, expected: %q, %s", lines[2], "the second dump with its timestamp and size")
+	}
+	if !strings.Contains(lines[3], "otherName_-_20190803120000.sql") {
+		t.Errorf("List dumps test failed; found, expected: %q, %s", lines[3], "the dump of the other database")
+	}
+
+	config.System.Type = "sender"
+	if err := listDumps(config, &out); err == nil {
+		t.Errorf("List dumps test failed; found, expected: %#v, %s", err, "receiver only err")
+	}
+}
+
+func TestResolveDump(t *testing.T) {
+
+	config := newWorkingDir(t)
+	jobs := config.ReceiverJobs()
+
+	var tests = []struct {
+		target string
+		dbName string
+		dump   string
+	}{
+		{"databaseName_-_20190803120000.sql.gz", "databaseName", "databaseName_-_20190803120000.sql.gz"},
+		{"20190802120000", "databaseName", "databaseName_-_20190802120000.sql.gz"},
+		{"otherName_-_20190803120000.sql", "otherName", "otherName_-_20190803120000.sql"},
+		// both databases have a dump at that time
+		{"20190803120000", "", ""},
+		{"20190804120000", "", ""},
+		{"2019080", "", ""},
+	}
+
+	for _, test := range tests {
+		job, dump, err := resolveDump(jobs, config.System.WorkingDir, test.target)
+		if test.dump == "" {
+			if err == nil {
+				t.Errorf("Resolve dump test failed for %s; found, expected: %s, %s", test.target, dump, "err")
+			}
+			continue
+		}
+		if err != nil || job.DBname != test.dbName || dump != test.dump {
+			t.Errorf("Resolve dump test failed for %s; found, expected: %s %s %#v, %s %s", test.target, job.DBname, dump, err, test.dbName, test.dump)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+
+	var tests = []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GiB"},
+	}
+
+	for _, test := range tests {
+		if found := humanSize(test.bytes); found != test.expected {
+			t.Errorf("Human size test failed; found, expected: %s, %s", found, test.expected)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"fmt"
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"sort"
+	"time"
+)
+
+// GFS is a grandfather-father-son retention policy, used in place of the CircularQueue when configured.
+// It keeps the newest dump of each of the last hourly hours, daily days, weekly (ISO) weeks and monthly months that
+// have a dump. A dump kept by any of them is kept
+type GFS struct {
+	hourly  int
+	daily   int
+	weekly  int
+	monthly int
+
+	// the retained backups, sorted from oldest to newest
+	backups []string
+}
+
+func (gfs *GFS) Make(hourly int, daily int, weekly int, monthly int) {
+
+	gfs.hourly = hourly
+	gfs.daily = daily
+	gfs.weekly = weekly
+	gfs.monthly = monthly
+	gfs.backups = nil
+}
+
+// Populate takes the sorted existing backups and returns the ones that expired
+func (gfs *GFS) Populate(sortedBackups []string) []string {
+
+	gfs.backups = append(gfs.backups, sortedBackups...)
+	return gfs.expire()
+}
+
+// Enqueue adds the newest backup and returns the ones that expired because of it
+func (gfs *GFS) Enqueue(backup string) []string {
+
+	gfs.backups = append(gfs.backups, backup)
+	return gfs.expire()
+}
+
+// Remove forgets the backups deleted by other means, e.g. the age and size limits
+func (gfs *GFS) Remove(backups []string) {
+
+	removed := make(map[string]bool)
+	for _, backup := range backups {
+		removed[backup] = true
+	}
+
+	var retained []string
+	for _, backup := range gfs.backups {
+		if !removed[backup] {
+			retained = append(retained, backup)
+		}
+	}
+	gfs.backups = retained
+}
+
+// drop the expired backups, returning them
+func (gfs *GFS) expire() []string {
+
+	keep := gfs.classify(gfs.backups)
+
+	var retained, expired []string
+	for _, backup := range gfs.backups {
+		if keep[backup] {
+			retained = append(retained, backup)
+		} else {
+			expired = append(expired, backup)
+		}
+	}
+	gfs.backups = retained
+
+	return expired
+}
+
+// classify returns the backups to keep. The backups are sorted from oldest to newest
+func (gfs *GFS) classify(sortedBackups []string) map[string]bool {
+
+	tiers := []struct {
+		keep   int
+		period func(time.Time) string
+	}{
+		{gfs.hourly, func(t time.Time) string { return t.Format("2006010215") }},
+		{gfs.daily, func(t time.Time) string { return t.Format("20060102") }},
+		{gfs.weekly, func(t time.Time) string { year, week := t.ISOWeek(); return fmt.Sprintf("%d-%02d", year, week) }},
+		{gfs.monthly, func(t time.Time) string { return t.Format("200601") }},
+	}
+
+	keep := make(map[string]bool)
+	for _, tier := range tiers {
+		seen := make(map[string]bool)
+
+		// newest first, so the newest dump of a period represents it
+		for i := len(sortedBackups) - 1; i >= 0 && len(seen) < tier.keep; i-- {
+			timeOfDump, err := parseBackupTimestamp(sortedBackups[i])
+			if err != nil {
+				// never delete what can't be classified
+				logging.Error(err, logging.Fields{})
+				keep[sortedBackups[i]] = true
+				continue
+			}
+			period := tier.period(timeOfDump)
+			if seen[period] {
+				continue
+			}
+			seen[period] = true
+			keep[sortedBackups[i]] = true
+		}
+	}
+
+	return keep
+}
+
+// beyondLimits returns the dumps older than maxAge, and the dumps that don't fit in maxSize counting from the newest.
+// Dumps are dated by the timestamp in their name, like the ring buffer and GFS retention do, an mtime changes when a
+// dump is copied. A maxAge or maxSize of 0 is no limit. The newest dump is always kept, so a stalled sender or a single
+// oversized dump never leaves the destination without a backup
+func beyondLimits(dumps []backup.Dump, maxAge time.Duration, maxSize int64, now time.Time) []string {
+
+	timestamps := make(map[string]time.Time)
+	var newestFirst []backup.Dump
+	for _, dump := range dumps {
+		timeOfDump, err := parseBackupTimestamp(dump.Name)
+		if err != nil {
+			// never delete what can't be dated
+			logging.Error(err, logging.Fields{Event: logging.Delete, Dump: dump.Name})
+			continue
+		}
+		timestamps[dump.Name] = timeOfDump
+		newestFirst = append(newestFirst, dump)
+	}
+	sort.SliceStable(newestFirst, func(i, j int) bool {
+		return timestamps[newestFirst[i].Name].After(timestamps[newestFirst[j].Name])
+	})
+
+	var expired []string
+	var totalSize int64
+	for i, dump := range newestFirst {
+		totalSize += dump.Size
+		if i == 0 {
+			continue
+		}
+		if (maxAge > 0 && now.Sub(timestamps[dump.Name]) > maxAge) || (maxSize > 0 && totalSize > maxSize) {
+			expired = append(expired, dump.Name)
+		}
+	}
+
+	return expired
+}
@@ -0,0 +1,163 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hourly dumps, oldest to newest
+func hourlyBackups(start time.Time, hours int) []string {
+	var backups []string
+	for i := 0; i < hours; i++ {
+		backups = append(backups, "databaseName_-_"+start.Add(time.Duration(i)*time.Hour).Format("20060102150405")+".sql.gz")
+	}
+	return backups
+}
+
+func TestGFS_Populate(t *testing.T) {
+
+	// 100 days of hourly dumps, ending on Sunday 2019-11-10 03:00
+	start := time.Date(2019, 8, 2, 4, 0, 0, 0, time.UTC)
+	backups := hourlyBackups(start, 100*24)
+
+	gfs := new(GFS)
+	gfs.Make(24, 14, 8, 12)
+	expired := gfs.Populate(backups)
+
+	if len(expired)+len(gfs.backups) != len(backups) {
+		t.Fatalf("GFS populate test failed; found, expected: %d, %d backups", len(expired)+len(gfs.backups), len(backups))
+	}
+
+	// the last 24 hours are kept as hourlies
+	for _, backup := range backups[len(backups)-24:] {
+		if !contains(gfs.backups, backup) {
+			t.Errorf("GFS populate test failed; hourly %s was not kept", backup)
+		}
+	}
+
+	// dailies are the last dump of a day, i.e. at 03:00 for the partial last day and 23:00 before that
+	for _, backup := range []string{"databaseName_-_20191109230000.sql.gz", "databaseName_-_20191028230000.sql.gz"} {
+		if !contains(gfs.backups, backup) {
+			t.Errorf("GFS populate test failed; daily %s was not kept", backup)
+		}
+	}
+	if contains(gfs.backups, "databaseName_-_20191026230000.sql.gz") {
+		t.Errorf("GFS populate test failed; daily %s was kept beyond 14 days", "databaseName_-_20191026230000.sql.gz")
+	}
+
+	// weeklies are the last dump of an ISO week, a Sunday
+	if !contains(gfs.backups, "databaseName_-_20190922230000.sql.gz") {
+		t.Errorf("GFS populate test failed; weekly %s was not kept", "databaseName_-_20190922230000.sql.gz")
+	}
+
+	// monthlies reach back to the first month
+	if !contains(gfs.backups, "databaseName_-_20190831230000.sql.gz") {
+		t.Errorf("GFS populate test failed; monthly %s was not kept", "databaseName_-_20190831230000.sql.gz")
+	}
+	if contains(gfs.backups, backups[0]) {
+		t.Errorf("GFS populate test failed; %s was kept", backups[0])
+	}
+
+	// the period representatives overlap, e.g. the newest dump is hourly, daily, weekly and monthly at once
+	if len(gfs.backups) >= 24+14+8+12 {
+		t.Errorf("GFS populate test failed; found, expected: %d, %s", len(gfs.backups), "less than 58 backups")
+	}
+}
+
+func TestGFS_Enqueue(t *testing.T) {
+
+	start := time.Date(2019, 8, 2, 0, 0, 0, 0, time.UTC)
+	backups := hourlyBackups(start, 4)
+
+	gfs := new(GFS)
+	gfs.Make(2, 0, 0, 0)
+	if expired := gfs.Populate(backups[:2]); len(expired) != 0 {
+		t.Errorf("GFS enqueue test failed; found, expected: %v, %s", expired, "nothing expired")
+	}
+
+	for i, backup := range backups[2:] {
+		expired := gfs.Enqueue(backup)
+		if strings.Join(expired, ",") != backups[i] {
+			t.Errorf("GFS enqueue test failed; found, expected: %v, %v", expired, []string{backups[i]})
+		}
+	}
+}
+
+func TestGFS_Remove(t *testing.T) {
+
+	backups := hourlyBackups(time.Date(2019, 8, 2, 0, 0, 0, 0, time.UTC), 4)
+
+	gfs := new(GFS)
+	gfs.Make(10, 0, 0, 0)
+	gfs.Populate(backups)
+
+	gfs.Remove(backups[:2])
+	if strings.Join(gfs.backups, ",") != strings.Join(backups[2:], ",") {
+		t.Errorf("GFS remove test failed; found, expected: %v, %v", gfs.backups, backups[2:])
+	}
+}
+
+func contains(backups []string, backup string) bool {
+	for _, b := range backups {
+		if b == backup {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBeyondLimits(t *testing.T) {
+
+	now := time.Date(2019, 9, 1, 0, 0, 0, 0, time.UTC)
+	var dumps []backup.Dump
+	for day := 1; day <= 40; day++ {
+		dumps = append(dumps, backup.Dump{
+			Name:    "databaseName_-_" + now.AddDate(0, 0, -day).Format("20060102150405") + ".sql.gz",
+			Size:    10 << 30,
+			ModTime: now.AddDate(0, 0, -day),
+		})
+	}
+
+	// older than 30 days
+	expired := beyondLimits(dumps, 30*24*time.Hour, 0, now)
+	if len(expired) != 10 || expired[0] != dumps[30].Name {
+		t.Errorf("Beyond limits age test failed; found, expected: %d, %d expired", len(expired), 10)
+	}
+
+	// 10GB dumps under 95GB
+	expired = beyondLimits(dumps, 0, 95<<30, now)
+	if len(expired) != 31 || expired[0] != dumps[9].Name {
+		t.Errorf("Beyond limits size test failed; found, expected: %d, %d expired", len(expired), 31)
+	}
+
+	// both limits together, the stricter wins
+	expired = beyondLimits(dumps, 30*24*time.Hour, 200<<30, now)
+	if len(expired) != 20 {
+		t.Errorf("Beyond limits test failed; found, expected: %d, %d expired", len(expired), 20)
+	}
+
+	// the newest dump is kept, no matter what
+	expired = beyondLimits(dumps, time.Hour, 1<<30, now)
+	if len(expired) != 39 || contains(expired, dumps[0].Name) {
+		t.Errorf("Beyond limits newest test failed; found, expected: %d, %d expired", len(expired), 39)
+	}
+
+	if expired = beyondLimits(dumps, 0, 0, now); len(expired) != 0 {
+		t.Errorf("Beyond limits test failed; found, expected: %d, %d expired", len(expired), 0)
+	}
+
+	// dumps are dated by their name. A copied dump has a new mtime, a stray file has no timestamp and is never deleted
+	copied := append([]backup.Dump{{Name: "stray.sql", Size: 1, ModTime: now.AddDate(-1, 0, 0)}}, dumps...)
+	for i := range copied {
+		copied[i].ModTime = now
+	}
+	expired = beyondLimits(copied, 30*24*time.Hour, 0, now)
+	if len(expired) != 10 || expired[0] != dumps[30].Name || contains(expired, "stray.sql") {
+		t.Errorf("Beyond limits name test failed; found, expected: %v, %d expired", expired, 10)
+	}
+}
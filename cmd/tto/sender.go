@@ -10,124 +10,232 @@ import (
 	"github.com/ctomkow/tto/cmd/tto/db"
 	"github.com/ctomkow/tto/cmd/tto/exec"
 	"github.com/ctomkow/tto/cmd/tto/inet"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"github.com/ctomkow/tto/cmd/tto/metrics"
+	"github.com/ctomkow/tto/cmd/tto/netio"
+	"github.com/ctomkow/tto/cmd/tto/notify"
 	"github.com/robfig/cron"
 	"net"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
 
+// a backup job of the sender, each with its own database, destinations and exec handler
+type job struct {
+	conf         conf.Job
+	dB           db.DB
+	destinations []*destination
+	exe          *exec.Exec
+
+	// a job is skipped by cron while its previous backup is still running
+	running bool
+
+	// the database when its binary logs are shipped, nil otherwise
+	binlogs db.Binlogs
+
+	// a backup waits while binary logs are being shipped, they share the database and connections
+	shipping      bool
+	backupPending bool
+
+	// a backup now is requested until the job starts it, it's running until the job is done. A backup now is never
+	// skipped, it waits for a running job as pending
+	nowRequested bool
+	nowRunning   bool
+
+	// the outcome of the last backup, once the job is done
+	err error
+}
+
+// a receiver, bucket or local directory the dumps of a job are streamed to, each with its own ring buffer
+// (or GFS retention, when configured) and remote connection
+type destination struct {
+	conf        conf.Destination
+	buf         *CircularQueue
+	gfs         *GFS
+	remote      backup.Destination
+	remoteAlive bool
+
+	// the retention has caught up with the dumps already at the destination, see sync
+	synced bool
+}
+
 func Sender(conf *conf.Config) error {
 
 	// setup various components
+	//   - locked pid file, for backup-now to find the daemon
 	//   - signal interrupts
-	//   - local database connection
-	//   - ring buffer for tracking database dumps
-	//   - ssh connection to remote host
-	//   - cron scheduling
-	//   - ticker to check on ssh connection
-	//   - os exec process handling
+	//   - a job per database, each with
+	//     - local database connection
+	//     - os exec process handling
+	//     - a destination per remote host, bucket or local directory, each with
+	//       - ring buffer for tracking database dumps
+	//       - ssh connection to remote host, or s3 connection to bucket
+	//   - cron scheduling, and SIGUSR1 for a backup now
+	//   - ticker to check on ssh connections
+	//   - ticker to ship binary logs
 
-	interrupt := newSignal()
-	dB := newSenderDb(
-		conf.System.Role.Sender.Database,
-		conf.System.Role.Sender.DBip,
-		conf.System.Role.Sender.DBport,
-		conf.System.Role.Sender.DBuser,
-		conf.System.Role.Sender.DBpass,
-		conf.System.Role.Sender.DBname,
-	)
-	buf := newRingBuf(conf.System.Role.Sender.MaxBackups)
-	remote := newSSH(
-		conf.System.Role.Sender.Dest,
-		conf.System.Role.Sender.Port,
-		conf.System.User,
-		conf.System.Pass,
-		conf.System.SSHkey,
-	)
-	cronChan, cronJob := newCron(conf.System.Role.Sender.Cron)
-	tickerChan, ticker := newTicker(60)
-	exe := newExecHandler()
-
-	// database dump prep and manipulation
-	//   - get the existing backups
-	//   - parse the multiline string into an array
-	//   - strip path
-	//   - add sorted backups to ring buffer
-	//   - delete backups that didn't fit into ring buffer
-	//   - start ticker that monitors ssh connection
-
-	if err := remote.Connect(); err != nil {
+	release, err := lockPid(conf.System.WorkingDir)
+	if err != nil {
 		return err
 	}
-	remoteAlive := true
-	multilineStringBackups, err := backup.Retrieve(remote, exe, conf.System.Role.Sender.DBname, conf.System.WorkingDir)
+	defer release()
+
+	interrupt := newSignal()
+	jobs, err := newJobs(conf)
 	if err != nil {
 		return err
 	}
-	backups, err := parseMultilineString(multilineStringBackups)
+	cronChan, cronJob, err := newCron(jobs)
 	if err != nil {
-		glog.Fatal(err)
+		return err
 	}
-	backups = stripPath(conf.System.WorkingDir, backups)
-	expiredDumps := fillBuf(buf, sortBackups(backups))
-	if err := backup.Delete(remote, exe, conf.System.WorkingDir, expiredDumps); err != nil {
-		glog.Error(err)
+	backupNow := newBackupSignal()
+	doneChan := make(chan *job)
+	tickerChan, ticker := newTicker(60)
+	binlogChan, binlogTicker := newTicker(binlogInterval(conf))
+
+	// database dump prep and manipulation
+	//   - sync the retention of each destination of each job with its existing backups, the ones that are down are
+	//     synced by the ticker once they are back
+	//   - connect to the databases whose binary logs are shipped
+	//   - start ticker that monitors ssh connection, and the one shipping binary logs
+
+	// the databases whose backup now failed, while backing up now
+	backingUpNow := false
+	var nowFailed []string
+
+	shipsBinlogs := false
+	for _, j := range jobs {
+		for _, d := range j.destinations {
+			if err := d.trySync(j.conf.DBname); err != nil {
+				return err
+			}
+		}
+		if j.binlogs != nil {
+			if err := attemptDB(j.binlogs, 3, 10); err != nil {
+				return err
+			}
+			shipsBinlogs = true
+		}
 	}
 	cronJob.Start()
 	startTicker(ticker, tickerChan)
+	if shipsBinlogs {
+		startTicker(binlogTicker, binlogChan)
+	}
 
 	for {
+		senderStatus.publish(jobs)
+
 		select {
-		// test ssh connection
+		// test ssh connections, a running job is busy with its connection
 		case <-tickerChan:
-			if err = remote.TestConnection(); err != nil {
-				glog.Error(err)
-			} else {
-				break
-			}
-			glog.Error("remote connection is down. backups are suspended until connection is re-established")
-			if err := remote.Reconnect(3, 10); err != nil {
-				glog.Error(err)
-				remoteAlive = false
-			} else {
-				remoteAlive = true
+			for _, j := range jobs {
+				if j.running {
+					continue
+				}
+				for _, d := range j.destinations {
+					if !d.synced {
+						if err = d.trySync(j.conf.DBname); err != nil {
+							return err
+						}
+						continue
+					}
+					if err = d.remote.TestConnection(); err == nil {
+						continue
+					}
+					fields := logging.Fields{Event: logging.Connection, DB: j.conf.DBname, Destination: d.remote.String()}
+					logging.Error(err, fields)
+					logging.Error(errors.New("remote connection to "+d.remote.String()+" is down. backups of "+j.conf.DBname+" to it are suspended until connection is re-established"), fields)
+					metrics.Add(metrics.Reconnects, 1, "db", j.conf.DBname, "destination", d.remote.String())
+					if err := d.remote.Reconnect(3, 10); err != nil {
+						if inet.IsHostKeyError(err) {
+							return err
+						}
+						logging.Error(err, fields)
+						d.remoteAlive = false
+					} else {
+						d.remoteAlive = true
+					}
+					d.report(j.conf.DBname)
+				}
 			}
 
 		// cron trigger
-		case <-cronChan:
-			if !remoteAlive {
-				glog.Error("remote is down")
+		case j := <-cronChan:
+			if j.running && j.shipping {
+				logging.Info("backup of "+j.conf.DBname+" waits for its binary logs to be shipped", logging.Fields{Event: logging.Dump, DB: j.conf.DBname})
+				j.backupPending = true
 				break
 			}
-
-			dumpStdout, err := dB.Dump(exe)
-			if err != nil {
-				glog.Error(err)
+			if j.running && j.nowRequested {
+				logging.Info("backup now of "+j.conf.DBname+" waits for its running backup", logging.Fields{Event: logging.Dump, DB: j.conf.DBname})
+				j.backupPending = true
 				break
 			}
-
-			err = backup.ToRemote(remote, conf.System.WorkingDir, dB.DumpName(), dumpStdout, exe)
-			if err != nil {
-				glog.Error(err)
+			if j.running {
+				logging.Error(errors.New("previous backup of "+j.conf.DBname+" is still running, skipping"), logging.Fields{Event: logging.Dump, DB: j.conf.DBname})
 				break
 			}
-			expiredDump := buf.Enqueue(dB.DumpName())
-			if expiredDump == "" {
-				break
+			startJob(conf, j, doneChan)
+
+		// backup now, the same way cron does. A busy job backs up once it's done
+		case <-backupNow:
+			logging.Info("backing up now", logging.Fields{Event: logging.Dump})
+			backingUpNow = true
+			for _, j := range jobs {
+				j.nowRequested = true
+				go cronTriggered(cronChan, j)
 			}
-			if err := backup.Delete(remote, exe, conf.System.WorkingDir, []string{expiredDump}); err != nil {
-				glog.Error(err)
-				break
+
+		// ship binary logs of the jobs that aren't busy, the others catch up on the next tick
+		case <-binlogChan:
+			for _, j := range jobs {
+				if j.binlogs == nil || j.running {
+					continue
+				}
+				j.running = true
+				j.shipping = true
+				go func(j *job) {
+					if err := shipBinlogs(conf, j); err != nil {
+						logging.Error(err, logging.Fields{Event: logging.Binlog, DB: j.conf.DBname})
+					}
+					doneChan <- j
+				}(j)
+			}
+
+		// trigger on job being finished
+		case j := <-doneChan:
+			if j.nowRunning && !j.shipping {
+				j.nowRunning = false
+				if j.err != nil {
+					nowFailed = append(nowFailed, j.conf.DBname)
+				}
+			}
+			j.running = false
+			j.shipping = false
+			if j.backupPending {
+				j.backupPending = false
+				go cronTriggered(cronChan, j)
+			}
+
+			// every job is done with the backup now, backup-now waits for the outcome
+			if backingUpNow && !pendingBackupNow(jobs) {
+				if err := writeBackupNowResult(conf.System.WorkingDir, nowFailed); err != nil {
+					logging.Error(err, logging.Fields{Event: logging.Dump})
+				}
+				backingUpNow = false
+				nowFailed = nil
 			}
 
 		// trigger on signal
 		case killSignal := <-interrupt:
 
-			glog.Error(killSignal)
+			logging.Error(errors.New(killSignal.String()), logging.Fields{})
 
 			if killSignal == os.Interrupt {
 				return errors.New("daemon was interrupted by system signal")
@@ -135,11 +243,309 @@ func Sender(conf *conf.Config) error {
 			return errors.New("daemon was killed")
 		}
 	}
+}
+
+// startJob runs a backup of the job. Jobs run concurrently, the job holds its running flag until it's sent on doneChan
+func startJob(conf *conf.Config, j *job, doneChan chan *job) {
+
+	j.running = true
+	j.nowRunning = j.nowRequested
+	j.nowRequested = false
+	go func() {
+		if _, j.err = runJob(conf, j); j.err != nil {
+			logging.Error(j.err, logging.Fields{Event: logging.Dump, DB: j.conf.DBname})
+		}
+		doneChan <- j
+	}()
+}
+
+// pendingBackupNow reports whether any job has yet to start or finish a backup now
+func pendingBackupNow(jobs []*job) bool {
+
+	for _, j := range jobs {
+		if j.nowRequested || j.nowRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// newJobs sets up a job for each database to back up. The jobs and destinations that can't be set up are left out,
+// their errors are returned together along with the jobs that could
+func newJobs(conf *conf.Config) ([]*job, error) {
+
+	var jobs []*job
+	var errs []error
+	seen := make(map[string]bool)
+
+	for _, jobConf := range conf.SenderJobs() {
+
+		dB := newSenderDb(jobConf.Database, jobConf.DBip, jobConf.DBport, jobConf.DBuser, jobConf.DBpass, jobConf.DBname)
+		if dB == nil {
+			errs = append(errs, errors.New("db "+jobConf.DBname+": unknown database: "+jobConf.Database))
+			continue
+		}
+		j := &job{conf: jobConf, dB: dB, exe: newExecHandler()}
+
+		if jobConf.ShipsBinlogs() {
+			binlogs, ok := dB.(db.Binlogs)
+			if !ok {
+				errs = append(errs, errors.New("db "+jobConf.DBname+": binlogs need a mysql database"))
+				continue
+			}
+			binlogs.RecordBinlogPosition()
+			j.binlogs = binlogs
+		}
+
+		for _, destConf := range jobConf.Destinations {
+
+			var remote backup.Destination
+			switch destConf.Type {
+			case "s3":
+				remote = backup.NewBucketDestination(newS3(destConf))
+			case "local":
+				if destConf.Path == "" {
+					errs = append(errs, errors.New("destination local of "+jobConf.DBname+": no path"))
+					continue
+				}
+				remote = backup.NewDirectoryDestination(destConf.Path)
+			case "", "ssh":
+				sh, err := newSSH(
+					destConf.Dest,
+					destConf.Port,
+					conf.System.User,
+					conf.System.Pass,
+					conf.System.SSHkey,
+					conf.System.KnownHosts,
+					destConf.HostKey,
+					conf.System.TOFU,
+				)
+				if err != nil {
+					errs = append(errs, errors.New("destination "+destConf.Dest.String()+" of "+jobConf.DBname+": "+err.Error()))
+					continue
+				}
+				remote = backup.NewSSHDestination(sh, j.exe, conf.System.WorkingDir, conf.System.Role.Sender.Transfer)
+			default:
+				errs = append(errs, errors.New("destination "+destConf.Type+" of "+jobConf.DBname+": unknown destination type"))
+				continue
+			}
+
+			// two jobs of the same database would fight over the same dumps on the remote
+			key := remote.String() + "/" + jobConf.DBname
+			if seen[key] {
+				errs = append(errs, errors.New("destination "+remote.String()+" of "+jobConf.DBname+": the database is backed up to it more than once"))
+				continue
+			}
+			seen[key] = true
+
+			d := &destination{conf: destConf, remote: remote}
+			if destConf.Retention.IsSet() {
+				d.gfs = newGFS(destConf.Retention)
+			} else {
+				d.buf = newRingBuf(destConf.MaxBackups)
+			}
+			j.destinations = append(j.destinations, d)
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, errors.Join(errs...)
+}
+
+// runJob dumps the database of the job, streams it to the destinations that are up and expires their oldest dumps.
+// Returns the dump, or an error when it wasn't stored as the destination policy requires
+func runJob(conf *conf.Config, j *job) (string, error) {
+
+	start := time.Now()
+	dumpName, err := backupJob(conf, j)
+	if err != nil {
+		metrics.Add(metrics.BackupFailures, 1, "db", j.conf.DBname)
+		notify.Failure(notify.Dump, j.conf.DBname, "", "", err)
+		return "", err
+	}
+	metrics.Set(metrics.BackupLastSuccess, float64(time.Now().Unix()), "db", j.conf.DBname)
+	metrics.Set(metrics.BackupDuration, time.Since(start).Seconds(), "db", j.conf.DBname)
+	logging.Info("backed up "+j.conf.DBname+": "+dumpName, logging.Fields{Event: logging.Dump, DB: j.conf.DBname, Dump: dumpName, Duration: time.Since(start)})
+	notify.Success(notify.Dump, j.conf.DBname, "", dumpName)
+
+	return dumpName, nil
+}
+
+func backupJob(conf *conf.Config, j *job) (string, error) {
+
+	requireAll := conf.System.Role.Sender.DestinationPolicy == "all"
+
+	var alive []*destination
+	var remotes []backup.Destination
+	for _, d := range j.destinations {
+		if !d.remoteAlive {
+			logging.Error(errors.New("remote "+d.remote.String()+" is down, skipping backup of "+j.conf.DBname+" to it"), logging.Fields{Event: logging.Transfer, DB: j.conf.DBname, Destination: d.remote.String()})
+			continue
+		}
+		alive = append(alive, d)
+		remotes = append(remotes, d.remote)
+	}
+	if len(alive) == 0 || (requireAll && len(alive) != len(j.destinations)) {
+		return "", errors.New("destinations are down, skipping backup of " + j.conf.DBname)
+	}
+
+	dumpStdout, err := j.dB.Dump(j.exe)
+	if err != nil {
+		return "", err
+	}
+
+	dumpName := j.dB.DumpName() + netio.CompressionExt(conf.System.Role.Sender.Compression) + netio.EncryptionExt(conf.System.Role.Sender.Recipients)
+	errs := backup.ToRemote(remotes, dumpName, dumpStdout, j.exe, conf.System.Role.Sender.Compression, conf.System.Role.Sender.Recipients, requireAll)
+
+	stored := 0
+	for i, d := range alive {
+		if errs[i] != nil {
+			logging.Error(errs[i], logging.Fields{Event: logging.Transfer, DB: j.conf.DBname, Dump: dumpName, Destination: d.remote.String()})
+			notify.Failure(notify.Transfer, j.conf.DBname, d.remote.String(), dumpName, errs[i])
+			continue
+		}
+		notify.Success(notify.Transfer, j.conf.DBname, d.remote.String(), dumpName)
+		stored++
+		d.delete(j.conf.DBname, d.retain(dumpName))
+		d.prune(j.conf.DBname)
+		d.report(j.conf.DBname)
+	}
+	if stored == 0 {
+		return "", errors.New("backup of " + j.conf.DBname + " failed at every destination")
+	}
+
+	return dumpName, nil
+}
+
+// sync connects to the destination and catches its retention up with the dumps already there
+//   - get the existing backups
+//   - add sorted backups to ring buffer
+//   - delete backups that didn't fit into ring buffer
+//   - delete backups beyond the age and size limits
+func (d *destination) sync(dbName string) error {
+	if err := d.remote.Connect(); err != nil {
+		return err
+	}
+	d.remoteAlive = true
+	dumps, err := d.remote.List(dbName)
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, dump := range dumps {
+		backups = append(backups, dump.Name)
+
+		// the last backup survives a restart
+		metrics.SetMax(metrics.TransferLastSuccess, float64(dump.ModTime.Unix()), "db", dbName, "destination", d.remote.String())
+		metrics.SetMax(metrics.BackupLastSuccess, float64(dump.ModTime.Unix()), "db", dbName)
+	}
+	d.delete(dbName, d.fill(sortBackups(backups)))
+	d.prune(dbName)
+	d.report(dbName)
+	d.synced = true
+	return nil
+}
+
+// trySync syncs the destination, or marks it down for the ticker to sync it later.
+// Only a host key error is returned, it won't fix itself
+func (d *destination) trySync(dbName string) error {
+	if err := d.sync(dbName); err != nil {
+		if inet.IsHostKeyError(err) {
+			return err
+		}
+		logging.Error(err, logging.Fields{Event: logging.Connection, DB: dbName, Destination: d.remote.String()})
+		d.remoteAlive = false
+		d.report(dbName)
+	}
 	return nil
 }
 
-func cronTriggered(c chan bool) {
-	c <- true
+// the backups kept by the ring buffer, or GFS retention, oldest first
+func (d *destination) backups() []string {
+	if d.gfs != nil {
+		return append([]string(nil), d.gfs.backups...)
+	}
+	return d.buf.Backups()
+}
+
+// report the state of the destination's retention and connection
+func (d *destination) report(dbName string) {
+	labels := []string{"db", dbName, "destination", d.remote.String()}
+	metrics.Set(metrics.Backups, float64(len(d.backups())), labels...)
+	if d.gfs == nil {
+		metrics.Set(metrics.MaxBackups, float64(d.conf.MaxBackups), labels...)
+	}
+	alive := 0.0
+	if d.remoteAlive {
+		alive = 1
+	}
+	metrics.Set(metrics.RemoteAlive, alive, labels...)
+}
+
+// prune deletes the dumps beyond the age and size limits of the destination, on top of what the ring buffer or GFS
+// retention expires. The deleted dumps are removed from the ring buffer or GFS retention, so they aren't counted
+func (d *destination) prune(dbName string) {
+	if d.conf.MaxAgeDays == 0 && d.conf.MaxSizeGB == 0 {
+		return
+	}
+
+	dumps, err := d.remote.List(dbName)
+	if err != nil {
+		logging.Error(err, logging.Fields{Event: logging.Delete, DB: dbName, Destination: d.remote.String()})
+		return
+	}
+	expired := beyondLimits(dumps, time.Duration(d.conf.MaxAgeDays)*24*time.Hour, int64(d.conf.MaxSizeGB)<<30, time.Now())
+	if err = d.delete(dbName, expired); err != nil {
+		return
+	}
+	d.forget(expired)
+}
+
+// remove deleted dumps from the ring buffer, or GFS retention
+func (d *destination) forget(deleted []string) {
+	if d.gfs != nil {
+		d.gfs.Remove(deleted)
+		return
+	}
+	d.buf.Remove(deleted)
+}
+
+// delete the expired dumps, or binary logs, from the destination
+func (d *destination) delete(dbName string, expired []string) error {
+	if len(expired) == 0 {
+		return nil
+	}
+
+	if err := d.remote.Delete(expired); err != nil {
+		logging.Error(err, logging.Fields{Event: logging.Delete, DB: dbName, Destination: d.remote.String()})
+		notify.Failure(notify.Delete, dbName, d.remote.String(), strings.Join(expired, ", "), err)
+		return err
+	}
+	notify.Success(notify.Delete, dbName, d.remote.String(), strings.Join(expired, ", "))
+	return nil
+}
+
+// fill the ring buffer, or GFS retention, with the sorted existing backups. Returns the ones that expired
+func (d *destination) fill(sortedBackups []string) []string {
+	if d.gfs != nil {
+		return fillGFS(d.gfs, sortedBackups)
+	}
+	return fillBuf(d.buf, sortedBackups)
+}
+
+// add the newest backup to the ring buffer, or GFS retention. Returns the ones that expired
+func (d *destination) retain(dumpName string) []string {
+	if d.gfs != nil {
+		return d.gfs.Enqueue(dumpName)
+	}
+	if expiredDump := d.buf.Enqueue(dumpName); expiredDump != "" {
+		return []string{expiredDump}
+	}
+	return nil
+}
+
+func cronTriggered(c chan *job, j *job) {
+	c <- j
 }
 
 // Setup channel on which to send signal notifications.
@@ -151,51 +557,92 @@ func newSignal() chan os.Signal {
 	return interrupt
 }
 
+// SIGUSR1 asks the running sender for a backup now
+func newBackupSignal() chan os.Signal {
+	backupNow := make(chan os.Signal, 1)
+	signal.Notify(backupNow, syscall.SIGUSR1)
+	return backupNow
+}
+
 // factory to setup chosen database
 func newSenderDb(impl string, ip net.IPAddr, port uint16, user string, pass string, name string) db.DB {
 	switch impl {
 	case "mysql":
 		return db.NewMysql(impl, ip, port, user, pass, name, 0)
 	case "postgres":
-		// pass
+		return db.NewPostgres(impl, ip, port, user, pass, name, 0)
 	default:
 		return nil
 	}
-	return nil
 }
 
 // create new ring buffer with a maximum size
 func newRingBuf(size int) *CircularQueue {
 	var buf = new(CircularQueue)
 	buf.Make(size)
-	glog.Info("maximum backups: " + strconv.Itoa(size))
+	logging.Info("maximum backups: "+strconv.Itoa(size), logging.Fields{Event: logging.Config})
 	return buf
 }
 
+// create new grandfather-father-son retention
+func newGFS(retention conf.Retention) *GFS {
+	var gfs = new(GFS)
+	gfs.Make(retention.Hourly, retention.Daily, retention.Weekly, retention.Monthly)
+	logging.Info("retention: "+strconv.Itoa(retention.Hourly)+" hourly, "+strconv.Itoa(retention.Daily)+" daily, "+
+		strconv.Itoa(retention.Weekly)+" weekly, "+strconv.Itoa(retention.Monthly)+" monthly", logging.Fields{Event: logging.Config})
+	return gfs
+}
+
 // setup new ssh connection with remote host
-func newSSH(ip net.IPAddr, port uint16, user string, pass string, key string) *inet.SSH {
+func newSSH(ip net.IPAddr, port uint16, user string, pass string, key string, knownHosts string, hostKey string, tofu bool) (*inet.SSH, error) {
 	var remoteConn = new(inet.SSH)
-	remoteConn.Make(ip.String(), strconv.FormatUint(uint64(port), 10), user, pass, key)
-	glog.Info("receiver host: " + ip.String())
-	return remoteConn
+	if err := remoteConn.Make(ip.String(), strconv.FormatUint(uint64(port), 10), user, pass, key, knownHosts, hostKey, tofu); err != nil {
+		return nil, err
+	}
+	logging.Info("receiver host: "+ip.String(), logging.Fields{Event: logging.Config, Destination: ip.String()})
+	return remoteConn, nil
+}
+
+// setup new s3 connection with bucket
+func newS3(destConf conf.Destination) *inet.S3 {
+	var bucket = new(inet.S3)
+	bucket.Make(destConf.Endpoint, destConf.Region, destConf.AccessKey, destConf.SecretKey, destConf.Bucket, destConf.Prefix)
+	logging.Info("s3 bucket: "+bucket.String(), logging.Fields{Event: logging.Config, Destination: bucket.String()})
+	return bucket
 }
 
 // fill ring buffer with provided sorted backup names
 func fillBuf(buf *CircularQueue, sortedBackups []string) []string {
 	expiredBuffElements := buf.Populate(sortedBackups)
-	for _, elem := range buf.queue[0:buf.size] {
-		glog.Info("existing backups: " + elem.name)
+	for _, elem := range buf.queue {
+		if elem.name != "" {
+			logging.Info("existing backups: "+elem.name, logging.Fields{Event: logging.Config, Dump: elem.name})
+		}
 	}
 	return expiredBuffElements
 }
 
-// create a channel and cronjob
-func newCron(schedule string) (chan bool, *cron.Cron) {
-	channel := make(chan bool)
+// fill GFS retention with provided sorted backup names
+func fillGFS(gfs *GFS, sortedBackups []string) []string {
+	expiredBackups := gfs.Populate(sortedBackups)
+	for _, elem := range gfs.backups {
+		logging.Info("existing backups: "+elem, logging.Fields{Event: logging.Config, Dump: elem})
+	}
+	return expiredBackups
+}
+
+// create a channel and a cronjob that sends each job on its own schedule
+func newCron(jobs []*job) (chan *job, *cron.Cron, error) {
+	channel := make(chan *job)
 	cj := cron.New()
-	cj.AddFunc(schedule, func() { cronTriggered(channel) })
-	glog.Info("db backup schedule: " + schedule)
-	return channel, cj
+	for _, j := range jobs {
+		j := j
+		if err := cj.AddFunc(j.conf.Cron, func() { cronTriggered(channel, j) }); err != nil {
+			return nil, nil, errors.New("cron of " + j.conf.DBname + ": " + err.Error())
+		}
+		logging.Info("db backup schedule of "+j.conf.DBname+": "+j.conf.Cron, logging.Fields{Event: logging.Config, DB: j.conf.DBname})
+	}
+	return channel, cj, nil
 }
 
 // create a channel and tick on every interval
@@ -0,0 +1,125 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"bufio"
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"github.com/ctomkow/tto/cmd/tto/exec"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// a database that dumps a fixed statement through an already exited process
+type fakeDB struct {
+	dumpName string
+}
+
+func (f *fakeDB) Open() error                                  { return nil }
+func (f *fakeDB) Create() error                                { return nil }
+func (f *fakeDB) Drop() error                                  { return nil }
+func (f *fakeDB) Restore(reader *bufio.Reader) error           { return nil }
+func (f *fakeDB) RestoreAtomic(reader *bufio.Reader) error     { return nil }
+func (f *fakeDB) RestoreCli(exe *exec.Exec, r io.Reader) error { return nil }
+func (f *fakeDB) Impl() string                                 { return "fake" }
+func (f *fakeDB) Name() string                                 { return "databaseName" }
+func (f *fakeDB) DumpName() string                             { return f.dumpName }
+
+func (f *fakeDB) Dump(exe *exec.Exec) (*io.ReadCloser, error) {
+	exe.LocalCmdOnly([]string{"true"})
+	if err := exe.Cmd.Start(); err != nil {
+		return nil, err
+	}
+	stdout := ioutil.NopCloser(strings.NewReader("CREATE TABLE t (id int);\n"))
+	return &stdout, nil
+}
+
+func TestRunJob(t *testing.T) {
+
+	dir := t.TempDir()
+	oldDump := "databaseName_-_20190802120000.sql"
+	if err := ioutil.WriteFile(filepath.Join(dir, oldDump), []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := new(conf.Config)
+	config.System.Role.Sender.Compression = "none"
+	d := &destination{conf: conf.Destination{Type: "local", Path: dir, MaxBackups: 1}, buf: newRingBuf(1), remote: backup.NewDirectoryDestination(dir)}
+	j := &job{conf: conf.Job{DBname: "databaseName"}, dB: &fakeDB{dumpName: "databaseName_-_20190803120000.sql"}, destinations: []*destination{d}, exe: newExecHandler()}
+
+	if err := d.sync(j.conf.DBname); err != nil {
+		t.Fatalf("Run job test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	dumpName, err := runJob(config, j)
+	if err != nil || dumpName != "databaseName_-_20190803120000.sql" {
+		t.Fatalf("Run job test failed; found, expected: %s %#v, %s", dumpName, err, "the new dump")
+	}
+
+	// the new dump pushed the old one out of the ring buffer
+	if _, err = os.Stat(filepath.Join(dir, dumpName)); err != nil {
+		t.Errorf("Run job test failed; found, expected: %#v, %s", err, "the new dump stored")
+	}
+	if _, err = os.Stat(filepath.Join(dir, oldDump)); !os.IsNotExist(err) {
+		t.Errorf("Run job test failed; found, expected: %#v, %s", err, "the old dump deleted")
+	}
+
+	// a job without any destination up fails
+	d.remoteAlive = false
+	if _, err = runJob(config, j); err == nil {
+		t.Errorf("Run job test failed; found, expected: %#v, %s", err, "destinations down err")
+	}
+}
+
+func TestTrySync(t *testing.T) {
+
+	dir := filepath.Join(t.TempDir(), "unmounted")
+	d := &destination{conf: conf.Destination{Type: "local", Path: dir, MaxBackups: 1}, buf: newRingBuf(1), remote: backup.NewDirectoryDestination(dir)}
+
+	// a destination that is down doesn't stop the sender, it's marked down until it's synced
+	if err := d.trySync("databaseName"); err != nil {
+		t.Fatalf("Try sync test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if d.remoteAlive || d.synced {
+		t.Errorf("Try sync test failed; found, expected: %v %v, %s", d.remoteAlive, d.synced, "down and not synced")
+	}
+
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.trySync("databaseName"); err != nil {
+		t.Fatalf("Try sync test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if !d.remoteAlive || !d.synced {
+		t.Errorf("Try sync test failed; found, expected: %v %v, %s", d.remoteAlive, d.synced, "up and synced")
+	}
+}
+
+func TestStartJob(t *testing.T) {
+
+	dir := t.TempDir()
+	config := new(conf.Config)
+	config.System.Role.Sender.Compression = "none"
+	d := &destination{conf: conf.Destination{Type: "local", Path: dir, MaxBackups: 1}, buf: newRingBuf(1), remote: backup.NewDirectoryDestination(dir)}
+	j := &job{conf: conf.Job{DBname: "databaseName"}, dB: &fakeDB{dumpName: "databaseName_-_20190803120000.sql"}, destinations: []*destination{d}, exe: newExecHandler()}
+	if err := d.sync(j.conf.DBname); err != nil {
+		t.Fatal(err)
+	}
+
+	// the backup now requested is the one that runs
+	j.nowRequested = true
+	doneChan := make(chan *job)
+	startJob(config, j, doneChan)
+	if !j.running || !j.nowRunning || j.nowRequested || !pendingBackupNow([]*job{j}) {
+		t.Errorf("Start job test failed; found, expected: %+v, %s", j, "running the backup now")
+	}
+
+	if done := <-doneChan; done != j || j.err != nil {
+		t.Errorf("Start job test failed; found, expected: %#v, %s", j.err, "nil err")
+	}
+}
@@ -0,0 +1,156 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"encoding/json"
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"github.com/ctomkow/tto/cmd/tto/logging"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// the state of the sender's jobs for /status, published by the sender loop that owns it
+var senderStatus = new(statusBoard)
+
+type statusBoard struct {
+	mu   sync.Mutex
+	jobs []jobStatus
+}
+
+// the status of a backup job of the sender, or a restore job of the receiver
+type jobStatus struct {
+	DB string `json:"db"`
+
+	// sender
+	Running      bool                `json:"running"`
+	Destinations []destinationStatus `json:"destinations,omitempty"`
+
+	// receiver
+	LatestDump    string `json:"latest_dump,omitempty"`
+	LatestRestore string `json:"latest_restore,omitempty"`
+	Restoring     bool   `json:"restoring"`
+}
+
+type destinationStatus struct {
+	Destination string   `json:"destination"`
+	Alive       bool     `json:"alive"`
+	Backups     []string `json:"backups"`
+}
+
+// a dump available to restore
+type dumpStatus struct {
+	DB        string    `json:"db"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// publish the state of the jobs. A running job owns its destinations, its last published state is kept
+func (board *statusBoard) publish(jobs []*job) {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	statuses := make([]jobStatus, len(jobs))
+	for i, j := range jobs {
+		if j.running && i < len(board.jobs) {
+			statuses[i] = board.jobs[i]
+			statuses[i].Running = true
+			continue
+		}
+		statuses[i] = jobStatus{DB: j.conf.DBname, Running: j.running}
+		if j.running {
+			continue
+		}
+		for _, d := range j.destinations {
+			statuses[i].Destinations = append(statuses[i].Destinations, destinationStatus{Destination: d.remote.String(), Alive: d.remoteAlive, Backups: d.backups()})
+		}
+	}
+	board.jobs = statuses
+}
+
+func (board *statusBoard) snapshot() []jobStatus {
+	board.mu.Lock()
+	defer board.mu.Unlock()
+
+	return append([]jobStatus(nil), board.jobs...)
+}
+
+// receiverStatus reads the state of the restore jobs from the working dir. A restore holds the restore lock,
+// whether the daemon or the restore command runs it
+func receiverStatus(conf *conf.Config) []jobStatus {
+
+	var statuses []jobStatus
+	for _, job := range conf.ReceiverJobs() {
+		statuses = append(statuses, jobStatus{
+			DB:            job.DBname,
+			LatestDump:    readFirstLine(conf.System.WorkingDir + backup.LatestDump(job.DBname)),
+			LatestRestore: readFirstLine(conf.System.WorkingDir + backup.LatestRestore(job.DBname)),
+			Restoring:     backup.RestoreLocked(conf.System.WorkingDir, job.DBname),
+		})
+	}
+	return statuses
+}
+
+// availableDumps returns the dumps of each database of the receiver, oldest first
+func availableDumps(conf *conf.Config) ([]dumpStatus, error) {
+
+	dumps := []dumpStatus{}
+	for _, job := range conf.ReceiverJobs() {
+		sorted, err := sortedDumps(conf.System.WorkingDir, job.DBname)
+		if err != nil {
+			return nil, err
+		}
+		for _, dump := range sorted {
+			timestamp, err := parseBackupTimestamp(dump.Name)
+			if err != nil {
+				return nil, err
+			}
+			dumps = append(dumps, dumpStatus{DB: job.DBname, Name: dump.Name, Size: dump.Size, Timestamp: timestamp})
+		}
+	}
+	return dumps, nil
+}
+
+// statusHandlers serves /healthz, /status and, on the receiver, /backups
+func statusHandlers(conf *conf.Config, mux *http.ServeMux) {
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		jobs := senderStatus.snapshot()
+		if conf.System.Type == "receiver" {
+			jobs = receiverStatus(conf)
+		}
+		writeJSON(w, http.StatusOK, struct {
+			Role string      `json:"role"`
+			Jobs []jobStatus `json:"jobs"`
+		}{Role: conf.System.Type, Jobs: jobs})
+	})
+
+	if conf.System.Type != "receiver" {
+		return
+	}
+	mux.HandleFunc("/backups", func(w http.ResponseWriter, r *http.Request) {
+		dumps, err := availableDumps(conf)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]dumpStatus{"backups": dumps})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Error(err, logging.Fields{})
+	}
+}
@@ -0,0 +1,111 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"encoding/json"
+	"github.com/ctomkow/tto/cmd/tto/backup"
+	"github.com/ctomkow/tto/cmd/tto/conf"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func get(t *testing.T, config *conf.Config, path string, v interface{}) int {
+
+	mux := http.NewServeMux()
+	statusHandlers(config, mux)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	if recorder.Code == http.StatusOK {
+		if err := json.NewDecoder(recorder.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return recorder.Code
+}
+
+func TestStatusHandlers_Receiver(t *testing.T) {
+
+	config := newWorkingDir(t)
+
+	var health map[string]string
+	if code := get(t, config, "/healthz", &health); code != http.StatusOK || health["status"] != "ok" {
+		t.Errorf("Healthz test failed; found, expected: %d %v, %d %s", code, health, http.StatusOK, "ok")
+	}
+
+	release, err := backup.LockRestore(config.System.WorkingDir, "otherName")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	var status struct {
+		Role string      `json:"role"`
+		Jobs []jobStatus `json:"jobs"`
+	}
+	get(t, config, "/status", &status)
+	if status.Role != "receiver" || len(status.Jobs) != 2 {
+		t.Fatalf("Receiver status test failed; found, expected: %+v, %s", status, "2 receiver jobs")
+	}
+	if status.Jobs[0].LatestRestore != "databaseName_-_20190802120000.sql.gz" || status.Jobs[0].Restoring {
+		t.Errorf("Receiver status test failed; found, expected: %+v, %s", status.Jobs[0], "the restored dump, not restoring")
+	}
+	if !status.Jobs[1].Restoring {
+		t.Errorf("Receiver status test failed; found, expected: %+v, %s", status.Jobs[1], "restoring")
+	}
+
+	// files without a dump timestamp are left out, they don't fail the listing
+	if err := ioutil.WriteFile(config.System.WorkingDir+"otherName_-_copy.sql.gz", []byte("stray"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var backups map[string][]dumpStatus
+	if code := get(t, config, "/backups", &backups); code != http.StatusOK {
+		t.Errorf("Receiver backups test failed; found, expected: %d, %d", code, http.StatusOK)
+	}
+	if len(backups["backups"]) != 3 || backups["backups"][1].Name != "databaseName_-_20190803120000.sql.gz" || backups["backups"][1].Size != 11 {
+		t.Errorf("Receiver backups test failed; found, expected: %+v, %s", backups, "3 dumps, oldest first")
+	}
+
+	// a working dir that can't be read is an error of the request, the daemon carries on
+	config.System.WorkingDir += "missing/"
+	if code := get(t, config, "/backups", nil); code != http.StatusInternalServerError {
+		t.Errorf("Receiver backups test failed; found, expected: %d, %d", code, http.StatusInternalServerError)
+	}
+}
+
+func TestStatusHandlers_Sender(t *testing.T) {
+
+	config := new(conf.Config)
+	config.System.Type = "sender"
+
+	buf := newRingBuf(2)
+	buf.Populate([]string{"databaseName_-_20190802120000.sql", "databaseName_-_20190803120000.sql"})
+	d := &destination{buf: buf, remote: backup.NewDirectoryDestination("/mnt/backups/"), remoteAlive: true}
+	jobs := []*job{{conf: conf.Job{DBname: "databaseName"}, destinations: []*destination{d}}}
+	senderStatus.publish(jobs)
+
+	// a running job keeps its last published state
+	jobs[0].running = true
+	d.remoteAlive = false
+	senderStatus.publish(jobs)
+
+	var status struct {
+		Role string      `json:"role"`
+		Jobs []jobStatus `json:"jobs"`
+	}
+	get(t, config, "/status", &status)
+	if status.Role != "sender" || len(status.Jobs) != 1 || !status.Jobs[0].Running || len(status.Jobs[0].Destinations) != 1 {
+		t.Fatalf("Sender status test failed; found, expected: %+v, %s", status, "1 running job")
+	}
+	if dest := status.Jobs[0].Destinations[0]; !dest.Alive || len(dest.Backups) != 2 || dest.Backups[0] != "databaseName_-_20190802120000.sql" {
+		t.Errorf("Sender status test failed; found, expected: %+v, %s", dest, "the backups in the ring, oldest first")
+	}
+
+	// no dumps to list on the sender
+	if code := get(t, config, "/backups", nil); code != http.StatusNotFound {
+		t.Errorf("Sender backups test failed; found, expected: %d, %d", code, http.StatusNotFound)
+	}
+}
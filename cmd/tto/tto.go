@@ -6,8 +6,12 @@ package main
 import (
 	"errors"
 	"github.com/ctomkow/tto/cmd/tto/conf"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/logging"
 	"github.com/takama/daemon"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Service struct {
@@ -18,7 +22,7 @@ const (
 	// name of the service
 	name        = "tto"
 	description = "3-2-1 go!"
-	usage       = "Usage: [flags] (install | remove | fg)"
+	usage       = "Usage: [flags] (install | remove | fg | backup-now | list | restore <name|timestamp> | restore-to <timestamp> [db_name] | check-config)"
 	flags       = `
 	--help
 		prints this message
@@ -32,13 +36,27 @@ const (
 		deletes the daemon manager script that was installed
 	fg
 		runs the program in the foreground. For process managers (docker, supervisord)
+	backup-now
+		backs up every database of the sender right away, exiting non-zero if any backup failed.
+		With the sender running, the daemon backs up and backup-now waits for it. Sending SIGUSR1 to the
+		running sender does the same, without waiting
+	list
+		lists the dumps available to restore on the receiver, with their timestamps and sizes
+	restore <name|timestamp>
+		restores the given dump on the receiver, by its name or timestamp (e.g. 20190802120000)
+	restore-to <timestamp> [db_name]
+		restores the database on the receiver to how it was at the timestamp (UTC), from the newest dump before it
+		and the shipped binary logs. db_name is needed when the receiver restores several databases
+	check-config
+		validates the configuration, reporting every error at once, then checks that the databases and
+		destinations can be reached. Exits non-zero if any check failed, without starting the daemon
 	`
 )
 
 func main() {
 
 	if err := conf.SetLogToStderr(); err != nil {
-		glog.Fatal(err)
+		fatal(err)
 	}
 	configFile := conf.SetConfFlag()
 	conf.SetUserUsage(usage, commands, flags)
@@ -46,26 +64,35 @@ func main() {
 
 	var cmd = new(conf.Command)
 	if err := cmd.MakeCmd(); err != nil {
-		glog.Fatal(err)
+		fatal(err)
 	}
 
 	if cmd.Install {
-		install()
+		if err := install(); err != nil {
+			fatal(err)
+		}
 	}
 
 	// daemon setup and service start
 	srv, err := daemon.New(name, description, daemon.SystemDaemon)
 	if err != nil {
-		glog.Fatal(err)
+		fatal(err)
 	}
 
 	service := &Service{srv}
 	status, err := service.Manage(cmd, configFile)
 	if err != nil {
-		glog.Fatal(err)
+		fatal(err)
 	}
-	glog.Info(status)
-	glog.Flush()
+	logging.Info(status, logging.Fields{})
+	logging.Flush()
+}
+
+// fatal logs the error tto can't go on after and exits non-zero
+func fatal(err error) {
+	logging.Error(err, logging.Fields{})
+	logging.Flush()
+	os.Exit(1)
 }
 
 // daemon manager
@@ -80,18 +107,72 @@ func (srv *Service) Manage(cmd *conf.Command, configFile *string) (string, error
 
 	} else if cmd.Fg {
 		// pass through
-		glog.Info("running in foreground")
-	} else {
-		glog.Fatal(usage)
+		logging.Info("running in foreground", logging.Fields{})
+	} else if cmd.CheckConfig {
+		if err := checkConfig("/etc/tto/"+*configFile, os.Stdout); err != nil {
+			return "", err
+		}
+		return "config is valid", nil
+	} else if !cmd.BackupNow && !cmd.List && !cmd.Restore && !cmd.RestoreTo {
+		return "", errors.New(usage)
 	}
 
 	var conf = new(conf.Config)
 	if err := conf.LoadConfig("/etc/tto/" + *configFile); err != nil {
-		glog.Exit(err)
+		return "", err
+	}
+	if err := logging.SetFormat(conf.System.LogFormat, conf.System.Type); err != nil {
+		return "", err
+	}
+	if err := setupNotifications(conf); err != nil {
+		return "", err
 	}
 
-	setupWorkingDir(conf)
-	setupPermissions(conf)
+	// on-demand commands run against the configured sender or receiver, next to the daemon
+	if cmd.BackupNow {
+		if conf.System.Type == "sender" {
+			// the daemon keeps track of its own dumps, a backup outside it would escape its retention
+			pid, err := signalSender(conf.System.WorkingDir)
+			if err != nil {
+				return "", err
+			}
+			if pid != 0 {
+				if err = waitBackupNow(conf.System.WorkingDir, time.Second); err != nil {
+					return "", err
+				}
+				return "the running sender (pid " + strconv.Itoa(pid) + ") backed up now", nil
+			}
+		}
+		dumps, err := runBackupNow(conf)
+		if err != nil {
+			return "", err
+		}
+		return "backed up db dumps: " + strings.Join(dumps, ", "), nil
+	} else if cmd.List {
+		return "listed db dumps", listDumps(conf, os.Stdout)
+	} else if cmd.Restore {
+		restoredDump, err := restoreDump(conf, cmd.Target)
+		if err != nil {
+			return "", err
+		}
+		return "restored db dump: " + restoredDump, nil
+	} else if cmd.RestoreTo {
+		restoredDump, err := restoreTo(conf, cmd.Target, cmd.DBname)
+		if err != nil {
+			return "", err
+		}
+		return "restored db dump: " + restoredDump + " and binary logs up to " + cmd.Target, nil
+	}
+
+	if err := setupWorkingDir(conf); err != nil {
+		return "", err
+	}
+	if err := setupPermissions(conf); err != nil {
+		return "", err
+	}
+	if err := serveHTTP(conf); err != nil {
+		return "", err
+	}
 
 	switch conf.System.Type {
 	case "sender":
@@ -4,82 +4,57 @@
 package main
 
 import (
-	"bufio"
 	"errors"
-	"github.com/golang/glog"
+	"github.com/ctomkow/tto/cmd/tto/logging"
 	"sort"
 	"strings"
 	"time"
 )
 
-// stripPath removes the provided path from each string of the slice of strings
-func stripPath(path string, filenames []string) []string {
-	var results []string
-	for _, filename := range filenames {
-		results = append(results, strings.Replace(filename, path, "", -1))
-	}
-	return results
-}
-
-// sortBackups returns a sorted string slice based on the timestamp in the filename of the backup
+// sortBackups returns the backup filenames sorted from oldest to newest, based on the timestamp in the filename.
+// The extension is kept as is, so plain (.sql) and compressed (.sql.gz, .sql.zst) dumps sort together.
+// Filenames without a timestamp, e.g. a stray file in the working dir, are logged and left out
 func sortBackups(filenames []string) []string {
-	var dbName string
-	var timeString string
-	var timestamps []time.Time
+	timestamps := make(map[string]time.Time)
 
+	var dumps []string
 	for _, filename := range filenames {
-		// grab before and after character sequence
-		splitStrings, err := splitOnDelimiter("_-_", filename)
-		if err != nil {
-			glog.Fatal(err)
-		}
-		dbName = splitStrings[0]
-		afterDash := splitStrings[1]
-
-		// grab before dot but after dash
-		splitStrings, err = splitOnDelimiter(".", afterDash)
+		timeOfDump, err := parseBackupTimestamp(filename)
 		if err != nil {
-			glog.Fatal(err)
+			logging.Warning("skipping "+filename+", not a dump: "+err.Error(), logging.Fields{Dump: filename})
+			continue
 		}
-		timeString = splitStrings[0]
-
-		// parse timestamp into time.Time
-		timeOfDump, err := parseTimeString(timeString)
-		if err != nil {
-			glog.Fatal(err)
-		}
-		timestamps = append(timestamps, timeOfDump)
+		timestamps[filename] = timeOfDump
+		dumps = append(dumps, filename)
 	}
 
-	sortedTimestamps := sortTimestamps(timestamps)
-
-	//re-compile the full filename
-	var dumps []string
-	for _, timestamp := range sortedTimestamps {
-
-		dumps = append(dumps, compileBackupFilename(dbName, timestamp))
-	}
+	sort.SliceStable(dumps, func(i, j int) bool { return timestamps[dumps[i]].Before(timestamps[dumps[j]]) })
 	return dumps
 }
 
 // ## parse helpers ##
 
-// compileBackupFilename returns a string of the full database backup filename
-func compileBackupFilename(dbName string, timestamp time.Time) string {
+// parseBackupTimestamp returns the time of the dump from a backup filename, e.g. dbName_-_20190802120000.sql.gz
+func parseBackupTimestamp(filename string) (time.Time, error) {
 
-	compiledString := dbName + "_-_" + timestamp.Format("20060102150405") + ".sql"
-	return compiledString
-}
+	// grab before and after character sequence
+	splitStrings, err := splitOnDelimiter("_-_", filename)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(splitStrings) < 2 {
+		return time.Time{}, errors.New("not a backup filename: " + filename)
+	}
+	afterDash := splitStrings[len(splitStrings)-1]
 
-// parseMultilineString takes a multiline string with '\n' delimiter
-// returns a slice of strings
-func parseMultilineString(str string) ([]string, error) {
-	var strSlice []string
-	scanner := bufio.NewScanner(strings.NewReader(str))
-	for scanner.Scan() {
-		strSlice = append(strSlice, scanner.Text())
+	// grab before dot but after dash
+	splitStrings, err = splitOnDelimiter(".", afterDash)
+	if err != nil {
+		return time.Time{}, err
 	}
-	return strSlice, nil
+
+	// parse timestamp into time.Time
+	return parseTimeString(splitStrings[0])
 }
 
 // splitOnDelimiter splits a string returning a string slice with all parts
@@ -99,21 +74,3 @@ func parseTimeString(timeStr string) (time.Time, error) {
 	}
 	return parsedTime, nil
 }
-
-// sortTimestamps orders the times from oldest to newest
-func sortTimestamps(timestamps []time.Time) []time.Time {
-	var sortedTimestamps []time.Time
-
-	// a hack to simplify sorting. O.O
-	var unixTimeSlice []int64
-	for _, timestamp := range timestamps {
-		unixTimeSlice = append(unixTimeSlice, timestamp.Unix())
-	}
-
-	sort.Slice(unixTimeSlice, func(i, j int) bool { return unixTimeSlice[i] < unixTimeSlice[j] })
-
-	for _, unixTime := range unixTimeSlice {
-		sortedTimestamps = append(sortedTimestamps, time.Unix(unixTime, 0).UTC())
-	}
-	return sortedTimestamps
-}
@@ -0,0 +1,46 @@
+// Craig Tomkow
+// October 18, 2026
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortBackups(t *testing.T) {
+
+	backups := []string{
+		"databaseName_-_20190803120000.sql.zst",
+		"databaseName_-_20190801120000.sql",
+		"databaseName_-_20190804120000.sql",
+		"databaseName_-_20190802120000.sql.gz",
+		"databaseName_-_backup.sql",
+	}
+	expected := []string{
+		"databaseName_-_20190801120000.sql",
+		"databaseName_-_20190802120000.sql.gz",
+		"databaseName_-_20190803120000.sql.zst",
+		"databaseName_-_20190804120000.sql",
+	}
+
+	sorted := sortBackups(backups)
+	if strings.Join(sorted, ",") != strings.Join(expected, ",") {
+		t.Errorf("Sort backups test failed; found, expected: %v, %v", sorted, expected)
+	}
+}
+
+func TestParseBackupTimestamp(t *testing.T) {
+
+	ts, err := parseBackupTimestamp("databaseName_-_20190802153045.sql.gz")
+	if err != nil {
+		t.Fatalf("Parse backup timestamp test failed; found, expected: %#v, %s", err, "nil err")
+	}
+	if ts.Format("20060102150405") != "20190802153045" {
+		t.Errorf("Parse backup timestamp test failed; found, expected: %s, %s", ts.Format("20060102150405"), "20190802153045")
+	}
+
+	if _, err = parseBackupTimestamp("conf.json"); err == nil {
+		t.Errorf("Parse backup timestamp test failed; found, expected: %#v, %s", err, "not nil err")
+	}
+}